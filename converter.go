@@ -0,0 +1,223 @@
+package dbr
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Converter converts between a struct field and the value stored in its column.
+// It's selected with an option of the field's db tag, eg `db:"settings,json"`.
+type Converter interface {
+	// ToDb returns the value to write for the field value v
+	ToDb(v interface{}) (interface{}, error)
+
+	// FromDb sets the field that dest points to from a column value, usually a []byte.
+	// It isn't called for NULL; the field is set to its zero value instead.
+	FromDb(value interface{}, dest interface{}) error
+}
+
+var (
+	converters = map[string]Converter{
+		"json":     jsonConverter{},
+		"csv":      csvConverter{},
+		"unixtime": unixTimeConverter{},
+	}
+	convertersMutex sync.RWMutex
+)
+
+// RegisterConverter makes c available as the db tag option name, replacing any converter of that name.
+// Converters must be registered before running queries on structs that use them, since struct mappings are cached.
+func RegisterConverter(name string, c Converter) {
+	convertersMutex.Lock()
+	defer convertersMutex.Unlock()
+	converters[name] = c
+}
+
+// converterFor returns the converter named by the first option of tag that is a registered converter, or nil
+func converterFor(tag dbTag) Converter {
+	convertersMutex.RLock()
+	defer convertersMutex.RUnlock()
+	for _, opt := range tag.Options {
+		if c, ok := converters[opt]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// fieldConvertersFor returns a map of column name to the converter of the struct field it maps to, for the fields that have one
+func fieldConvertersFor(recordType reflect.Type) map[string]Converter {
+	fieldConvertersCacheMutex.RLock()
+	convs, ok := fieldConvertersCache[recordType]
+	fieldConvertersCacheMutex.RUnlock()
+
+	if ok {
+		return convs
+	}
+
+	convs = map[string]Converter{}
+	for column, fieldIndex := range fieldIndexFor(recordType) {
+		var fieldStruct reflect.StructField
+		t := recordType
+		for _, x := range fieldIndex {
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			fieldStruct = t.Field(x)
+			t = fieldStruct.Type
+		}
+
+		if c := converterFor(parseDbTag(fieldStruct.Tag.Get("db"))); c != nil {
+			convs[column] = c
+		}
+	}
+
+	fieldConvertersCacheMutex.Lock()
+	fieldConvertersCache[recordType] = convs
+	fieldConvertersCacheMutex.Unlock()
+
+	return convs
+}
+
+// columnBytes returns the raw bytes of a scanned column value
+func columnBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("dbr: can't convert column value of type %T", value)
+}
+
+// isNilValue reports whether v is a nil pointer, map, slice or interface
+func isNilValue(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return v == nil
+}
+
+// jsonConverter stores a field as a JSON document. Nil pointers, maps and slices are stored as NULL.
+type jsonConverter struct{}
+
+func (jsonConverter) ToDb(v interface{}) (interface{}, error) {
+	if isNilValue(v) {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (jsonConverter) FromDb(value interface{}, dest interface{}) error {
+	b, err := columnBytes(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dest)
+}
+
+// csvConverter stores a []string field as a comma-separated list, quoting elements as needed.
+// A nil slice is stored as NULL.
+type csvConverter struct{}
+
+func (csvConverter) ToDb(v interface{}) (interface{}, error) {
+	strs, ok := v.([]string)
+	if !ok {
+		return nil, fmt.Errorf("dbr: csv converter needs a []string, got %T", v)
+	}
+	if strs == nil {
+		return nil, nil
+	}
+	if len(strs) == 0 {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(strs)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return string(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
+
+func (csvConverter) FromDb(value interface{}, dest interface{}) error {
+	strs, ok := dest.(*[]string)
+	if !ok {
+		return fmt.Errorf("dbr: csv converter needs a []string, got %T", dest)
+	}
+	b, err := columnBytes(value)
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		*strs = []string{}
+		return nil
+	}
+
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	record, err := r.Read()
+	if err != nil {
+		return err
+	}
+	*strs = record
+	return nil
+}
+
+// unixTimeConverter stores a time.Time or *time.Time field as seconds since the epoch. A nil *time.Time is stored as NULL.
+type unixTimeConverter struct{}
+
+func (unixTimeConverter) ToDb(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Unix(), nil
+	case *time.Time:
+		if t == nil {
+			return nil, nil
+		}
+		return t.Unix(), nil
+	}
+	return nil, fmt.Errorf("dbr: unixtime converter needs a time.Time, got %T", v)
+}
+
+func (unixTimeConverter) FromDb(value interface{}, dest interface{}) error {
+	var secs int64
+	switch v := value.(type) {
+	case int64:
+		secs = v
+	default:
+		b, err := columnBytes(value)
+		if err != nil {
+			return err
+		}
+		secs, err = strconv.ParseInt(string(b), 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+
+	t := time.Unix(secs, 0)
+	switch d := dest.(type) {
+	case *time.Time:
+		*d = t
+	case **time.Time:
+		*d = &t
+	default:
+		return fmt.Errorf("dbr: unixtime converter needs a time.Time, got %T", dest)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package dbr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type documentSettings struct {
+	Theme  string `json:"theme"`
+	Alerts bool   `json:"alerts"`
+}
+
+type dbrDocument struct {
+	Id       int64
+	Settings documentSettings `db:"settings,json"`
+	Tags     []string         `db:"tags,csv"`
+	SeenAt   *time.Time       `db:"seen_at,unixtime"`
+}
+
+func TestConverterToSql(t *testing.T) {
+	s := createFakeSession()
+
+	seenAt := time.Unix(1400000000, 0)
+	doc := &dbrDocument{Settings: documentSettings{Theme: "dark"}, Tags: []string{"a", "b,c"}, SeenAt: &seenAt}
+
+	sql, args, err := s.InsertInto("dbr_documents").Columns("settings", "tags", "seen_at").Record(doc).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO dbr_documents (`settings`,`tags`,`seen_at`) VALUES (?,?,?)")
+	assert.Equal(t, args, []interface{}{`{"theme":"dark","alerts":false}`, `a,"b,c"`, int64(1400000000)})
+
+	doc.Tags = nil
+	doc.SeenAt = nil
+	sql, args, err = s.Update("dbr_documents").SetRecord(doc, "tags", "seen_at").Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "UPDATE dbr_documents SET `tags` = ?, `seen_at` = ? WHERE (id = ?)")
+	assert.Equal(t, args, []interface{}{nil, nil, 1})
+}
+
+func TestConverterRoundTrip(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	seenAt := time.Unix(1400000000, 0)
+	doc := &dbrDocument{Settings: documentSettings{Theme: "dark", Alerts: true}, Tags: []string{"a", "b,c"}, SeenAt: &seenAt}
+	_, err := s.InsertInto("dbr_documents").Columns("settings", "tags", "seen_at").Record(doc).Exec()
+	assert.NoError(t, err)
+
+	_, err = s.InsertInto("dbr_documents").Columns("settings", "tags", "seen_at").Record(&dbrDocument{}).Exec()
+	assert.NoError(t, err)
+
+	var docs []*dbrDocument
+	count, err := s.Select("*").From("dbr_documents").OrderBy("id").LoadStructs(&docs)
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	if len(docs) == 2 {
+		assert.Equal(t, docs[0].Settings, documentSettings{Theme: "dark", Alerts: true})
+		assert.Equal(t, docs[0].Tags, []string{"a", "b,c"})
+		if assert.NotNil(t, docs[0].SeenAt) {
+			assert.True(t, docs[0].SeenAt.Equal(seenAt))
+		}
+
+		assert.Equal(t, docs[1].Settings, documentSettings{})
+		assert.Nil(t, docs[1].Tags)
+		assert.Nil(t, docs[1].SeenAt)
+	}
+
+	_, err = s.Update("dbr_documents").SetRecord(&dbrDocument{Tags: []string{"x"}}, "tags").Where("id = ?", docs[0].Id).Exec()
+	assert.NoError(t, err)
+
+	var doc2 dbrDocument
+	err = s.Select("id", "tags").From("dbr_documents").Where("id = ?", docs[0].Id).LoadStruct(&doc2)
+	assert.NoError(t, err)
+	assert.Equal(t, doc2.Tags, []string{"x"})
+}
+
+type upperConverter struct{}
+
+func (upperConverter) ToDb(v interface{}) (interface{}, error) {
+	return strings.ToUpper(v.(string)), nil
+}
+
+func (upperConverter) FromDb(value interface{}, dest interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("upper: not bytes")
+	}
+	*dest.(*string) = strings.ToLower(string(b))
+	return nil
+}
+
+type shoutingPerson struct {
+	Id   int64
+	Name string `db:"name,upper"`
+}
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter("upper", upperConverter{})
+
+	s := createRealSessionWithFixtures()
+
+	res, err := s.InsertInto("dbr_people").Columns("name").Record(&shoutingPerson{Name: "Barack"}).Exec()
+	assert.NoError(t, err)
+	id, err := res.LastInsertId()
+	assert.NoError(t, err)
+
+	name, err := s.Select("name").From("dbr_people").Where("id = ?", id).ReturnString()
+	assert.NoError(t, err)
+	assert.Equal(t, name, "BARACK")
+
+	var person shoutingPerson
+	err = s.Select("id", "name").From("dbr_people").Where("id = ?", id).LoadStruct(&person)
+	assert.NoError(t, err)
+	assert.Equal(t, person.Name, "barack")
+}
@@ -15,6 +15,19 @@ type Connection struct {
 type Session struct {
 	cxn *Connection
 	EventReceiver
+
+	// StrictMapping makes LoadStruct and LoadStructs return a *MappingError when the query
+	// returns columns the struct doesn't have, or doesn't return columns for the struct's fields.
+	// Tag fields with db:"-" to leave them out.
+	StrictMapping bool
+
+	// PanicOnBuildError makes builders panic on invalid input, such as a missing table or an invalid
+	// Where argument, as they used to. By default the error is returned by ToSql, Exec and the Load* methods.
+	PanicOnBuildError bool
+
+	selectScopes map[string][]SelectScope
+	updateScopes map[string][]UpdateScope
+	deleteScopes map[string][]DeleteScope
 }
 
 // NewConnection instantiates a Connection for a given database/sql connection
@@ -37,7 +50,7 @@ func (cxn *Connection) NewSession(log EventReceiver) *Session {
 
 // SessionRunner can do anything that a Session can except start a transaction.
 type SessionRunner interface {
-	Select(cols ...string) *SelectBuilder
+	Select(cols ...interface{}) *SelectBuilder
 	SelectBySql(sql string, args ...interface{}) *SelectBuilder
 
 	InsertInto(into string) *InsertBuilder
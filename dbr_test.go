@@ -84,6 +84,23 @@ func installFixtures(db *sql.DB) {
 		)
 	`
 
+	createAddressesTable := `
+		CREATE TABLE dbr_addresses (
+			id int(11) DEFAULT NULL auto_increment PRIMARY KEY,
+			person_id int(11) NULL,
+			city varchar(255) NOT NULL
+		)
+	`
+
+	createDocumentsTable := `
+		CREATE TABLE dbr_documents (
+			id int(11) DEFAULT NULL auto_increment PRIMARY KEY,
+			settings text NULL,
+			tags varchar(255) NULL,
+			seen_at bigint NULL
+		)
+	`
+
 	sqlToRun := []string{
 		"DROP TABLE IF EXISTS dbr_people",
 		createPeopleTable,
@@ -92,6 +109,15 @@ func installFixtures(db *sql.DB) {
 
 		"DROP TABLE IF EXISTS null_types",
 		createNullTypesTable,
+
+		"DROP TABLE IF EXISTS dbr_addresses",
+		createAddressesTable,
+		"INSERT INTO dbr_addresses (person_id,city) VALUES (1, 'Boulder')",
+		"INSERT INTO dbr_addresses (person_id,city) VALUES (1, 'San Francisco')",
+		"INSERT INTO dbr_addresses (person_id,city) VALUES (NULL, 'Nowhere')",
+
+		"DROP TABLE IF EXISTS dbr_documents",
+		createDocumentsTable,
 	}
 
 	for _, v := range sqlToRun {
@@ -3,6 +3,7 @@ package dbr
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -12,6 +13,7 @@ type DeleteBuilder struct {
 	*Session
 	runner
 
+	OptimizerHints []string
 	From           string
 	WhereFragments []*whereFragment
 	OrderBys       []string
@@ -19,6 +21,10 @@ type DeleteBuilder struct {
 	LimitValid     bool
 	OffsetCount    uint64
 	OffsetValid    bool
+
+	immutable bool
+	unscoped  bool
+	err       error
 }
 
 // DeleteFrom creates a new DeleteBuilder for the given table
@@ -40,21 +46,61 @@ func (tx *Tx) DeleteFrom(from string) *DeleteBuilder {
 	}
 }
 
+// Clone returns a copy of the builder that can be extended without affecting the original
+func (b *DeleteBuilder) Clone() *DeleteBuilder {
+	c := *b
+	c.OptimizerHints = cloneStrings(b.OptimizerHints)
+	c.WhereFragments = cloneWhereFragments(b.WhereFragments)
+	c.OrderBys = cloneStrings(b.OrderBys)
+	return &c
+}
+
+// Immutable returns a copy of the builder on which every chained call returns a modified copy
+// instead of changing the builder itself
+func (b *DeleteBuilder) Immutable() *DeleteBuilder {
+	c := b.Clone()
+	c.immutable = true
+	return c
+}
+
+func (b *DeleteBuilder) mutable() *DeleteBuilder {
+	if b.immutable {
+		return b.Clone()
+	}
+	return b
+}
+
+// OptimizerHint appends an optimizer hint such as "MAX_EXECUTION_TIME(1000)" to the statement.
+// MySQL doesn't allow index hints on a single-table DELETE, so there are none here.
+func (b *DeleteBuilder) OptimizerHint(hint string) *DeleteBuilder {
+	b = b.mutable()
+	b.OptimizerHints = append(b.OptimizerHints, hint)
+	return b
+}
+
 // Where appends a WHERE clause to the statement whereSqlOrMap can be a
 // string or map. If it's a string, args wil replaces any places holders
 func (b *DeleteBuilder) Where(whereSqlOrMap interface{}, args ...interface{}) *DeleteBuilder {
-	b.WhereFragments = append(b.WhereFragments, newWhereFragment(whereSqlOrMap, args))
+	b = b.mutable()
+	fragment, err := newWhereFragment(whereSqlOrMap, args)
+	if err != nil {
+		b.recordError(err)
+		return b
+	}
+	b.WhereFragments = append(b.WhereFragments, fragment)
 	return b
 }
 
 // OrderBy appends an ORDER BY clause to the statement
 func (b *DeleteBuilder) OrderBy(ord string) *DeleteBuilder {
+	b = b.mutable()
 	b.OrderBys = append(b.OrderBys, ord)
 	return b
 }
 
 // OrderDir appends an ORDER BY clause with a direction to the statement
 func (b *DeleteBuilder) OrderDir(ord string, isAsc bool) *DeleteBuilder {
+	b = b.mutable()
 	if isAsc {
 		b.OrderBys = append(b.OrderBys, ord+" ASC")
 	} else {
@@ -65,6 +111,7 @@ func (b *DeleteBuilder) OrderDir(ord string, isAsc bool) *DeleteBuilder {
 
 // Limit sets a LIMIT clause for the statement; overrides any existing LIMIT
 func (b *DeleteBuilder) Limit(limit uint64) *DeleteBuilder {
+	b = b.mutable()
 	b.LimitCount = limit
 	b.LimitValid = true
 	return b
@@ -72,28 +119,50 @@ func (b *DeleteBuilder) Limit(limit uint64) *DeleteBuilder {
 
 // Offset sets an OFFSET clause for the statement; overrides any existing OFFSET
 func (b *DeleteBuilder) Offset(offset uint64) *DeleteBuilder {
+	b = b.mutable()
 	b.OffsetCount = offset
 	b.OffsetValid = true
 	return b
 }
 
+// recordError records err, the first error building the statement, to be returned by ToSql and Exec
+func (b *DeleteBuilder) recordError(err error) {
+	err = b.buildError(err)
+	if b.err == nil {
+		b.err = err
+	}
+}
+
 // ToSql serialized the DeleteBuilder to a SQL string
-// It returns the string with placeholders and a slice of query arguments
-func (b *DeleteBuilder) ToSql() (string, []interface{}) {
+// It returns the string with placeholders and a slice of query arguments,
+// or an error if the statement is invalid
+func (b *DeleteBuilder) ToSql() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
+	if scoped := b.withDefaultScopes(); scoped != b {
+		return scoped.ToSql()
+	}
+
 	if len(b.From) == 0 {
-		panic("no table specified")
+		return "", nil, b.buildError(errors.New("no table specified"))
 	}
 
 	var sql bytes.Buffer
 	var args []interface{}
 
-	sql.WriteString("DELETE FROM ")
+	sql.WriteString("DELETE ")
+	writeOptimizerHintsToSql(b.OptimizerHints, &sql)
+	sql.WriteString("FROM ")
 	sql.WriteString(b.From)
 
 	// Write WHERE clause if we have any fragments
 	if len(b.WhereFragments) > 0 {
 		sql.WriteString(" WHERE ")
-		writeWhereFragmentsToSql(b.WhereFragments, &sql, &args)
+		if err := writeWhereFragmentsToSql(b.WhereFragments, &sql, &args); err != nil {
+			return "", nil, b.buildError(err)
+		}
 	}
 
 	// Ordering and limiting
@@ -117,17 +186,20 @@ func (b *DeleteBuilder) ToSql() (string, []interface{}) {
 		fmt.Fprint(&sql, b.OffsetCount)
 	}
 
-	return sql.String(), args
+	return sql.String(), args, nil
 }
 
 // Exec executes the statement represented by the DeleteBuilder
 // It returns the raw database/sql Result and an error if there was one
 func (b *DeleteBuilder) Exec() (sql.Result, error) {
-	sql, args := b.ToSql()
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return nil, b.errorKv("dbr.delete.exec.to_sql", sql, err, nil)
+	}
 
 	fullSql, err := Interpolate(sql, args)
 	if err != nil {
-		return nil, b.EventErrKv("dbr.delete.exec.interpolate", err, kvs{"sql": fullSql})
+		return nil, b.errorKv("dbr.delete.exec.interpolate", sql, err, kvs{"sql": fullSql})
 	}
 
 	// Start the timer:
@@ -136,8 +208,18 @@ func (b *DeleteBuilder) Exec() (sql.Result, error) {
 
 	result, err := b.runner.Exec(fullSql)
 	if err != nil {
-		return result, b.EventErrKv("dbr.delete.exec.exec", err, kvs{"sql": fullSql})
+		return result, b.errorKv("dbr.delete.exec.exec", sql, err, kvs{"sql": fullSql})
 	}
 
 	return result, nil
 }
+
+// errorKv reports err to the EventReceiver and returns it wrapped in an *Error with sql, the statement built so far, if any
+func (b *DeleteBuilder) errorKv(eventName, sql string, err error, kvs kvs) error {
+	if kvs == nil {
+		err = b.EventErr(eventName, err)
+	} else {
+		err = b.EventErrKv(eventName, err, kvs)
+	}
+	return newError("delete", b.From, sql, err)
+}
@@ -18,7 +18,8 @@ func BenchmarkDeleteSql(b *testing.B) {
 func TestDeleteAllToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, _ := s.DeleteFrom("a").ToSql()
+	sql, _, err := s.DeleteFrom("a").ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "DELETE FROM a")
 }
@@ -26,7 +27,8 @@ func TestDeleteAllToSql(t *testing.T) {
 func TestDeleteSingleToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.DeleteFrom("a").Where("id = ?", 1).ToSql()
+	sql, args, err := s.DeleteFrom("a").Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "DELETE FROM a WHERE (id = ?)")
 	assert.Equal(t, args, []interface{}{1})
@@ -35,11 +37,49 @@ func TestDeleteSingleToSql(t *testing.T) {
 func TestDeleteTenStaringFromTwentyToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, _ := s.DeleteFrom("a").Limit(10).Offset(20).OrderBy("id").ToSql()
+	sql, _, err := s.DeleteFrom("a").Limit(10).Offset(20).OrderBy("id").ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "DELETE FROM a ORDER BY id LIMIT 10 OFFSET 20")
 }
 
+func TestDeleteHintsToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.DeleteFrom("a").OptimizerHint("BKA(a)").Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql, "DELETE /*+ BKA(a) */ FROM a WHERE (id = ?)")
+	assert.Equal(t, args, []interface{}{1})
+}
+
+func TestDeleteImmutable(t *testing.T) {
+	s := createFakeSession()
+
+	base := s.DeleteFrom("a").Where("b = ?", 1).Immutable()
+	limited := base.Where("c = ?", 2).Limit(5)
+
+	sql, args, err := base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "DELETE FROM a WHERE (b = ?)")
+	assert.Equal(t, args, []interface{}{1})
+
+	sql, args, err = limited.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "DELETE FROM a WHERE (b = ?) AND (c = ?) LIMIT 5")
+	assert.Equal(t, args, []interface{}{1, 2})
+}
+
+func TestDeleteBuildErrors(t *testing.T) {
+	s := createFakeSession()
+
+	_, _, err := s.DeleteFrom("").ToSql()
+	assert.EqualError(t, err, "no table specified")
+
+	_, err = s.DeleteFrom("a").Where(1).Exec()
+	assert.EqualError(t, err, "dbr: delete a: Invalid argument passed to Where. Pass a string or an Eq map.")
+}
+
 func TestDeleteReal(t *testing.T) {
 	s := createRealSessionWithFixtures()
 
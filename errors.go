@@ -1,7 +1,14 @@
 package dbr
 
 import (
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 var (
@@ -11,4 +18,123 @@ var (
 	ErrInvalidSliceValue  = errors.New("trying to interpolate invalid slice value into query")
 	ErrInvalidValue       = errors.New("trying to interpolate invalid value into query")
 	ErrArgumentMismatch   = errors.New("mismatch between ? (placeholders) and arguments")
+	ErrDuplicateMapKey    = errors.New("more than one row has the same map key")
+	ErrColumnMismatch     = errors.New("mismatch between destinations and selected columns")
+)
+
+// Error is returned when running a statement or transaction fails.
+// It wraps the cause, which can be checked with errors.Is and errors.As, eg errors.Is(err, ErrColumnMismatch).
+// ErrNotFound is returned as is, since it isn't a failure.
+type Error struct {
+	Op    string // select, insert, update, delete, begin, commit or rollback
+	Table string // the table of the statement, if any
+	Sql   string // the statement with placeholders rather than values, if any
+	Err   error
+}
+
+func (e *Error) Error() string {
+	msg := strings.TrimPrefix(e.Err.Error(), "dbr: ")
+	if e.Table != "" {
+		return fmt.Sprintf("dbr: %s %s: %s", e.Op, e.Table, msg)
+	}
+	return fmt.Sprintf("dbr: %s: %s", e.Op, msg)
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError wraps err in an *Error, unless it already is one
+func newError(op, table, sql string, err error) error {
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	return &Error{Op: op, Table: table, Sql: sql, Err: err}
+}
+
+// buildError returns err, an error building a statement, or panics with it if the session sets PanicOnBuildError
+func (sess *Session) buildError(err error) error {
+	if sess.PanicOnBuildError {
+		panic(err.Error())
+	}
+	return err
+}
+
+// MySQL (and MariaDB) server error numbers
+const (
+	mysqlErrDupKey              = 1022
+	mysqlErrDupEntry            = 1062
+	mysqlErrDupEntryWithKeyName = 1586
+	mysqlErrLockWaitTimeout     = 1205
+	mysqlErrLockDeadlock        = 1213
+	mysqlErrNoReferencedRow     = 1216
+	mysqlErrRowIsReferenced     = 1217
+	mysqlErrRowIsReferenced2    = 1451
+	mysqlErrNoReferencedRow2    = 1452
+	mysqlErrServerGone          = 2006
+	mysqlErrServerLost          = 2013
 )
+
+// sqlStater is implemented by the errors of drivers that report a SQLSTATE, like the Postgres drivers
+type sqlStater interface {
+	SQLState() string
+}
+
+// classify reports whether err is a MySQL error with one of the numbers, or a driver error with a SQLSTATE starting with one of the states
+func classify(err error, numbers []uint16, states []string) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		for _, n := range numbers {
+			if mysqlErr.Number == n {
+				return true
+			}
+		}
+		return false
+	}
+
+	var stater sqlStater
+	if errors.As(err, &stater) {
+		state := stater.SQLState()
+		for _, s := range states {
+			if strings.HasPrefix(state, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsDuplicateKey reports whether err was caused by a unique key violation
+func IsDuplicateKey(err error) bool {
+	return classify(err, []uint16{mysqlErrDupKey, mysqlErrDupEntry, mysqlErrDupEntryWithKeyName}, []string{"23505"})
+}
+
+// IsDeadlock reports whether err was caused by the transaction being chosen as a deadlock victim.
+// The transaction has been rolled back and can be retried.
+func IsDeadlock(err error) bool {
+	return classify(err, []uint16{mysqlErrLockDeadlock}, []string{"40P01"})
+}
+
+// IsLockWaitTimeout reports whether err was caused by timing out waiting for a row lock
+func IsLockWaitTimeout(err error) bool {
+	return classify(err, []uint16{mysqlErrLockWaitTimeout}, []string{"55P03"})
+}
+
+// IsForeignKeyViolation reports whether err was caused by a foreign key constraint, either because the
+// referenced row doesn't exist or because the row is still referenced
+func IsForeignKeyViolation(err error) bool {
+	return classify(err, []uint16{mysqlErrNoReferencedRow, mysqlErrRowIsReferenced, mysqlErrRowIsReferenced2, mysqlErrNoReferencedRow2}, []string{"23503"})
+}
+
+// IsConnectionError reports whether err was caused by a broken connection to the server rather than by the statement
+func IsConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return classify(err, []uint16{mysqlErrServerGone, mysqlErrServerLost}, []string{"08"})
+}
@@ -0,0 +1,69 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestErrorWrapping(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	_, err := s.InsertInto("dbr_people").Columns("id", "name").Values(1, "Dup").Exec()
+	assert.True(t, IsDuplicateKey(err))
+
+	var dbrErr *Error
+	if assert.True(t, errors.As(err, &dbrErr)) {
+		assert.Equal(t, dbrErr.Op, "insert")
+		assert.Equal(t, dbrErr.Table, "dbr_people")
+		assert.Equal(t, dbrErr.Sql, "INSERT INTO dbr_people (`id`,`name`) VALUES (?,?)")
+	}
+
+	var count int64
+	err = s.Select("id", "name").From("dbr_people").LoadValue(&count)
+	assert.True(t, errors.Is(err, ErrColumnMismatch))
+	assert.Equal(t, err.Error(), "dbr: select dbr_people: mismatch between destinations and selected columns")
+	if assert.True(t, errors.As(err, &dbrErr)) {
+		assert.Equal(t, dbrErr.Sql, "SELECT id, name FROM dbr_people")
+	}
+
+	// ErrNotFound isn't wrapped
+	err = s.Select("id").From("dbr_people").Where("id = ?", 0).LoadValue(&count)
+	assert.Equal(t, err, ErrNotFound)
+}
+
+func TestErrorClassification(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("wrapped: %w", &Error{Op: "update", Table: "t", Err: err})
+	}
+
+	assert.True(t, IsDuplicateKey(wrap(&mysql.MySQLError{Number: 1062})))
+	assert.True(t, IsDuplicateKey(wrap(sqlStateError("23505"))))
+	assert.False(t, IsDuplicateKey(wrap(&mysql.MySQLError{Number: 1213})))
+
+	assert.True(t, IsDeadlock(wrap(&mysql.MySQLError{Number: 1213})))
+	assert.True(t, IsDeadlock(wrap(sqlStateError("40P01"))))
+
+	assert.True(t, IsLockWaitTimeout(wrap(&mysql.MySQLError{Number: 1205})))
+	assert.False(t, IsLockWaitTimeout(wrap(errors.New("1205"))))
+
+	assert.True(t, IsForeignKeyViolation(wrap(&mysql.MySQLError{Number: 1451})))
+	assert.True(t, IsForeignKeyViolation(wrap(&mysql.MySQLError{Number: 1452})))
+	assert.True(t, IsForeignKeyViolation(wrap(sqlStateError("23503"))))
+
+	assert.True(t, IsConnectionError(wrap(driver.ErrBadConn)))
+	assert.True(t, IsConnectionError(wrap(mysql.ErrInvalidConn)))
+	assert.True(t, IsConnectionError(wrap(&mysql.MySQLError{Number: 2006})))
+	assert.True(t, IsConnectionError(wrap(sqlStateError("08006"))))
+	assert.False(t, IsConnectionError(wrap(&mysql.MySQLError{Number: 1062})))
+	assert.False(t, IsConnectionError(nil))
+}
@@ -0,0 +1,114 @@
+package dbr
+
+import (
+	"strings"
+)
+
+// ExplainRow is one row of MySQL's tabular EXPLAIN output
+type ExplainRow struct {
+	Id           NullInt64
+	SelectType   NullString
+	Table        NullString
+	Partitions   NullString
+	Type         NullString
+	PossibleKeys NullString
+	Key          NullString
+	KeyLen       NullString
+	Ref          NullString
+	Rows         NullInt64
+	Filtered     NullFloat64
+	Extra        NullString `db:"Extra"`
+}
+
+// QueryPlan is the result of EXPLAIN for a statement, one row per table access
+type QueryPlan []*ExplainRow
+
+// IsFullTableScan returns true if MySQL reads every row of the table (access type ALL)
+func (r *ExplainRow) IsFullTableScan() bool {
+	return r.Type.Valid && r.Type.String == "ALL"
+}
+
+// UsesFilesort returns true if MySQL needs an extra sorting pass for this table
+func (r *ExplainRow) UsesFilesort() bool {
+	return r.Extra.Valid && strings.Contains(r.Extra.String, "Using filesort")
+}
+
+// FullTableScans returns the rows of the plan that scan a whole table
+func (p QueryPlan) FullTableScans() []*ExplainRow {
+	var rows []*ExplainRow
+	for _, r := range p {
+		if r.IsFullTableScan() {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// Filesorts returns the rows of the plan that require a filesort
+func (p QueryPlan) Filesorts() []*ExplainRow {
+	var rows []*ExplainRow
+	for _, r := range p {
+		if r.UsesFilesort() {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// Explain runs EXPLAIN for the statement and returns the query plan
+func (b *SelectBuilder) Explain() (QueryPlan, error) {
+	return explain(b.Session, b.runner, b)
+}
+
+// ExplainJSON runs EXPLAIN FORMAT=JSON for the statement and returns the JSON document
+func (b *SelectBuilder) ExplainJSON() (string, error) {
+	return explainJSON(b.Session, b.runner, b)
+}
+
+// Explain runs EXPLAIN for the statement and returns the query plan
+func (b *UpdateBuilder) Explain() (QueryPlan, error) {
+	return explain(b.Session, b.runner, b)
+}
+
+// ExplainJSON runs EXPLAIN FORMAT=JSON for the statement and returns the JSON document
+func (b *UpdateBuilder) ExplainJSON() (string, error) {
+	return explainJSON(b.Session, b.runner, b)
+}
+
+// Explain runs EXPLAIN for the statement and returns the query plan
+func (b *DeleteBuilder) Explain() (QueryPlan, error) {
+	return explain(b.Session, b.runner, b)
+}
+
+// ExplainJSON runs EXPLAIN FORMAT=JSON for the statement and returns the JSON document
+func (b *DeleteBuilder) ExplainJSON() (string, error) {
+	return explainJSON(b.Session, b.runner, b)
+}
+
+type sqlGenerator interface {
+	ToSql() (string, []interface{}, error)
+}
+
+// explainBuilder wraps the statement in a SelectBuilder so that EXPLAIN goes through the usual loading and instrumentation
+func explainBuilder(sess *Session, r runner, prefix string, stmt sqlGenerator) *SelectBuilder {
+	sql, args, err := stmt.ToSql()
+	return &SelectBuilder{
+		Session:      sess,
+		runner:       r,
+		RawFullSql:   prefix + sql,
+		RawArguments: args,
+		err:          err,
+	}
+}
+
+func explain(sess *Session, r runner, stmt sqlGenerator) (QueryPlan, error) {
+	var plan QueryPlan
+	_, err := explainBuilder(sess, r, "EXPLAIN ", stmt).LoadStructs(&plan)
+	return plan, err
+}
+
+func explainJSON(sess *Session, r runner, stmt sqlGenerator) (string, error) {
+	var doc string
+	err := explainBuilder(sess, r, "EXPLAIN FORMAT=JSON ", stmt).LoadValue(&doc)
+	return doc, err
+}
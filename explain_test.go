@@ -0,0 +1,34 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryPlanFlags(t *testing.T) {
+	scan := &ExplainRow{}
+	scan.Table.Scan("posts")
+	scan.Type.Scan("ALL")
+	scan.Extra.Scan("Using where; Using filesort")
+
+	lookup := &ExplainRow{}
+	lookup.Table.Scan("users")
+	lookup.Type.Scan("eq_ref")
+	lookup.Key.Scan("PRIMARY")
+
+	plan := QueryPlan{scan, lookup}
+
+	assert.Equal(t, plan.FullTableScans(), []*ExplainRow{scan})
+	assert.Equal(t, plan.Filesorts(), []*ExplainRow{scan})
+	assert.Equal(t, lookup.IsFullTableScan(), false)
+	assert.Equal(t, lookup.UsesFilesort(), false)
+}
+
+func TestSelectExplain(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	plan, err := s.Select("id").From("dbr_people").Where("name = ?", "Jonathan").OrderBy("email").Explain()
+	assert.NoError(t, err)
+	assert.True(t, len(plan) > 0)
+}
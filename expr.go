@@ -3,6 +3,8 @@ package dbr
 type expr struct {
 	Sql    string
 	Values []interface{}
+
+	err error // set when the expression couldn't be built, eg a subquery from SelectBuilder.As
 }
 
 // Expr is a SQL fragment with placeholders, and a slice of args to replace them with
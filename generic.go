@@ -0,0 +1,61 @@
+//go:build go1.18
+// +build go1.18
+
+package dbr
+
+import (
+	"reflect"
+)
+
+// All executes the SelectBuilder and returns all rows as a []T
+// T is a struct or a pointer to a struct, loaded like LoadStructs, or any other type that can be scanned, loaded like LoadValues
+//
+// Example:
+//
+//	users, err := dbr.All[*User](sess.Select("*").From("users"))
+//	ids, err := dbr.All[int64](sess.Select("id").From("users"))
+func All[T any](b *SelectBuilder) ([]T, error) {
+	var dest []T
+	var err error
+	if isStructType(reflect.TypeOf(dest).Elem()) {
+		_, err = b.LoadStructs(&dest)
+	} else {
+		_, err = b.LoadValues(&dest)
+	}
+	return dest, err
+}
+
+// One executes the SelectBuilder and returns the first row as a T
+// T is a struct or a pointer to a struct, loaded like LoadStruct, or any other type that can be scanned, loaded like LoadValue
+// Returns ErrNotFound if nothing was found
+func One[T any](b *SelectBuilder) (T, error) {
+	var dest T
+	var err error
+	t := reflect.TypeOf(&dest).Elem()
+	switch {
+	case isStructType(t) && t.Kind() == reflect.Ptr:
+		v := reflect.New(t.Elem())
+		if err = b.LoadStruct(v.Interface()); err == nil {
+			dest = v.Interface().(T)
+		}
+	case isStructType(t):
+		err = b.LoadStruct(&dest)
+	default:
+		err = b.LoadValue(&dest)
+	}
+	return dest, err
+}
+
+// Values executes the SelectBuilder and returns the single selected column of all rows as a []T
+// T is any type that can be scanned, including sql.Scanner implementations and pointers for NULLable columns
+func Values[T any](b *SelectBuilder) ([]T, error) {
+	var dest []T
+	_, err := b.LoadValues(&dest)
+	return dest, err
+}
+
+// isStructType returns whether t is a struct or pointer to a struct that's loaded field by field,
+// rather than scanned as a single value like time.Time or a sql.Scanner
+func isStructType(t reflect.Type) bool {
+	return nestedStructType(t) != nil
+}
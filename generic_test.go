@@ -0,0 +1,71 @@
+//go:build go1.18
+// +build go1.18
+
+package dbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericAll(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	people, err := All[*dbrPerson](s.Select("*").From("dbr_people").OrderBy("id"))
+	assert.NoError(t, err)
+	assert.Equal(t, len(people), 2)
+	if len(people) == 2 {
+		assert.Equal(t, people[0].Name, "Jonathan")
+		assert.Equal(t, people[1].Name, "Dmitri")
+	}
+
+	values, err := All[dbrPerson](s.Select("id", "name").From("dbr_people").OrderBy("id"))
+	assert.NoError(t, err)
+	assert.Equal(t, len(values), 2)
+
+	names, err := All[string](s.Select("name").From("dbr_people").OrderBy("id"))
+	assert.NoError(t, err)
+	assert.Equal(t, names, []string{"Jonathan", "Dmitri"})
+
+	none, err := All[*dbrPerson](s.Select("*").From("dbr_people").Where("id = ?", 0))
+	assert.NoError(t, err)
+	assert.Equal(t, len(none), 0)
+}
+
+func TestGenericOne(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	person, err := One[*dbrPerson](s.Select("*").From("dbr_people").Where("name = ?", "Dmitri"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, person) {
+		assert.Equal(t, person.Email.String, "zavorotni@jadius.com")
+	}
+
+	value, err := One[dbrPerson](s.Select("*").From("dbr_people").Where("name = ?", "Dmitri"))
+	assert.NoError(t, err)
+	assert.Equal(t, value.Name, "Dmitri")
+
+	count, err := One[int64](s.Select("COUNT(*)").From("dbr_people"))
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(2))
+
+	missing, err := One[*dbrPerson](s.Select("*").From("dbr_people").Where("id = ?", 0))
+	assert.Equal(t, err, ErrNotFound)
+	assert.Nil(t, missing)
+}
+
+func TestGenericValues(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	ids, err := Values[int64](s.Select("id").From("dbr_people").OrderBy("id"))
+	assert.NoError(t, err)
+	assert.Equal(t, ids, []int64{1, 2})
+
+	keys, err := Values[NullString](s.Select("`key`").From("dbr_people"))
+	assert.NoError(t, err)
+	assert.Equal(t, len(keys), 2)
+	for _, key := range keys {
+		assert.False(t, key.Valid)
+	}
+}
@@ -0,0 +1,36 @@
+package dbr
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Index and optimizer hints are MySQL-specific. Index hints are written after the table name
+// (USE INDEX (idx_a, idx_b)) and optimizer hints are written as a single comment right after the
+// statement's leading keyword (SELECT /*+ MAX_EXECUTION_TIME(1000) */ ...). The hints of joined tables are
+// written after them in the From string, since that's where joins are written.
+
+func indexHint(kind string, indexes []string) string {
+	return kind + " INDEX (" + strings.Join(indexes, ", ") + ")"
+}
+
+func writeOptimizerHintsToSql(hints []string, sql *bytes.Buffer) {
+	if len(hints) == 0 {
+		return
+	}
+	sql.WriteString("/*+ ")
+	for i, h := range hints {
+		if i > 0 {
+			sql.WriteRune(' ')
+		}
+		sql.WriteString(h)
+	}
+	sql.WriteString(" */ ")
+}
+
+func writeIndexHintsToSql(hints []string, sql *bytes.Buffer) {
+	for _, h := range hints {
+		sql.WriteRune(' ')
+		sql.WriteString(h)
+	}
+}
@@ -0,0 +1,127 @@
+package dbr
+
+import (
+	"reflect"
+)
+
+// AfterLoader is implemented by records that post-process themselves after LoadStruct, LoadStructs,
+// LoadStructsMap or LoadGrouped fill them in, and after any preloads. An error aborts the load.
+type AfterLoader interface {
+	AfterLoad() error
+}
+
+// BeforeInserter is implemented by records that prepare themselves, eg by setting defaults,
+// before an InsertBuilder's Exec or ExecBatch reads their values. An error aborts the insert.
+// Records passed by value are copied when BeforeInsert has a pointer receiver, so its changes aren't seen by the caller.
+type BeforeInserter interface {
+	BeforeInsert() error
+}
+
+// BeforeUpdater is implemented by records that prepare themselves before an UpdateBuilder's Exec
+// reads their values for SetRecord. An error aborts the update.
+// Records passed by value are copied when BeforeUpdate has a pointer receiver, so its changes aren't seen by the caller.
+type BeforeUpdater interface {
+	BeforeUpdate() error
+}
+
+var typeOfAfterLoader = reflect.TypeOf((*AfterLoader)(nil)).Elem()
+
+// afterLoad calls AfterLoad on each element of records, a slice of structs or pointers to structs
+func afterLoad(records reflect.Value) error {
+	recordType := records.Type().Elem()
+	if recordType.Kind() != reflect.Ptr {
+		recordType = reflect.PtrTo(recordType)
+	}
+	if !recordType.Implements(typeOfAfterLoader) {
+		return nil
+	}
+
+	for i := 0; i < records.Len(); i++ {
+		record := records.Index(i)
+		if record.Kind() != reflect.Ptr {
+			record = record.Addr()
+		}
+		if err := record.Interface().(AfterLoader).AfterLoad(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	typeOfBeforeInserter = reflect.TypeOf((*BeforeInserter)(nil)).Elem()
+	typeOfBeforeUpdater  = reflect.TypeOf((*BeforeUpdater)(nil)).Elem()
+)
+
+// hookable returns record, or a pointer to a copy of it if it was passed by value
+// and only its pointer implements the hook, and whether it implements the hook at all
+func hookable(record interface{}, hook reflect.Type) (interface{}, bool) {
+	v := reflect.ValueOf(record)
+	if !v.IsValid() {
+		return record, false
+	}
+	if v.Type().Implements(hook) {
+		return record, true
+	}
+	if v.Kind() != reflect.Ptr && reflect.PtrTo(v.Type()).Implements(hook) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr.Interface(), true
+	}
+	return record, false
+}
+
+// beforeInsert calls BeforeInsert on each record that implements it, and returns the records to insert.
+// A record passed by value whose BeforeInsert has a pointer receiver is replaced by a hooked copy.
+func beforeInsert(records []interface{}) ([]interface{}, error) {
+	hooked := records
+	for i, rec := range records {
+		hookedRec, ok := hookable(rec, typeOfBeforeInserter)
+		if !ok {
+			continue
+		}
+		if err := hookedRec.(BeforeInserter).BeforeInsert(); err != nil {
+			return nil, err
+		}
+		if reflect.TypeOf(hookedRec) != reflect.TypeOf(rec) {
+			if &hooked[0] == &records[0] {
+				hooked = append([]interface{}(nil), records...)
+			}
+			hooked[i] = hookedRec
+		}
+	}
+	return hooked, nil
+}
+
+// beforeUpdate calls BeforeUpdate on the record of each SetRecord call that implements it, and returns the set clauses
+// to build the statement from. A record passed by value whose BeforeUpdate has a pointer receiver is replaced by a hooked copy.
+func beforeUpdate(clauses []*setClause) ([]*setClause, error) {
+	hooked := clauses
+	var prev, prevHooked *updateRecord
+	for i, c := range clauses {
+		if c.record == nil {
+			continue
+		}
+		if c.record != prev {
+			prev, prevHooked = c.record, c.record
+
+			rec, ok := hookable(c.record.value, typeOfBeforeUpdater)
+			if !ok {
+				continue
+			}
+			if err := rec.(BeforeUpdater).BeforeUpdate(); err != nil {
+				return nil, err
+			}
+			if reflect.TypeOf(rec) != reflect.TypeOf(c.record.value) {
+				prevHooked = &updateRecord{value: rec}
+			}
+		}
+		if prevHooked != c.record {
+			if &hooked[0] == &clauses[0] {
+				hooked = append([]*setClause(nil), clauses...)
+			}
+			hooked[i] = &setClause{column: c.column, record: prevHooked}
+		}
+	}
+	return hooked, nil
+}
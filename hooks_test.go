@@ -0,0 +1,128 @@
+package dbr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookedPerson struct {
+	Id    int64
+	Name  string
+	Email NullString
+
+	Initial string `db:"-"`
+	fail    bool
+}
+
+func (p *hookedPerson) AfterLoad() error {
+	p.Initial = p.Name[:1]
+	return nil
+}
+
+func (p *hookedPerson) BeforeInsert() error {
+	if p.fail {
+		return errors.New("no inserts")
+	}
+	if !p.Email.Valid {
+		p.Email.String = strings.ToLower(p.Name) + "@example.com"
+		p.Email.Valid = true
+	}
+	return nil
+}
+
+func (p *hookedPerson) BeforeUpdate() error {
+	if p.fail {
+		return errors.New("no updates")
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	return nil
+}
+
+func TestAfterLoadHook(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var people []hookedPerson
+	count, err := s.Select("*").From("dbr_people").OrderBy("id").LoadStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	if len(people) == 2 {
+		assert.Equal(t, people[0].Initial, "J")
+		assert.Equal(t, people[1].Initial, "D")
+	}
+
+	var person hookedPerson
+	err = s.Select("*").From("dbr_people").Where("name = ?", "Dmitri").LoadStruct(&person)
+	assert.NoError(t, err)
+	assert.Equal(t, person.Initial, "D")
+
+	var byId map[int64]*hookedPerson
+	_, err = s.Select("*").From("dbr_people").LoadStructsMap(&byId, "id")
+	assert.NoError(t, err)
+	if assert.NotNil(t, byId[1]) {
+		assert.Equal(t, byId[1].Initial, "J")
+	}
+}
+
+func TestBeforeInsertHook(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// ToSql doesn't run the hooks
+	person := &hookedPerson{Name: "Barack"}
+	sql, args, err := s.InsertInto("dbr_people").Columns("name", "email").Record(person).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO dbr_people (`name`,`email`) VALUES (?,?)")
+	assert.Equal(t, args, []interface{}{"Barack", NullString{}})
+	assert.False(t, person.Email.Valid)
+
+	person = &hookedPerson{Name: "Barack"}
+	_, err = s.InsertInto("dbr_people").Columns("name", "email").Record(person).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, person.Email.String, "barack@example.com")
+
+	_, err = s.InsertInto("dbr_people").Columns("name", "email").Record(&hookedPerson{Name: "Fail", fail: true}).Exec()
+	assert.EqualError(t, err, "dbr: insert dbr_people: no inserts")
+
+	// A record passed by value is hooked on a copy
+	_, err = s.InsertInto("dbr_people").Columns("name", "email").Record(hookedPerson{Name: "Joe"}).Exec()
+	assert.NoError(t, err)
+
+	email, err := s.Select("email").From("dbr_people").Where("name = ?", "Joe").ReturnString()
+	assert.NoError(t, err)
+	assert.Equal(t, email, "joe@example.com")
+
+	count, err := s.Select("COUNT(*)").From("dbr_people").ReturnInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(4))
+}
+
+func TestBeforeUpdateHook(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	person := &hookedPerson{Name: "  Jon  "}
+	_, err := s.Update("dbr_people").SetRecord(person, "name").Where("id = ?", 1).Exec()
+	assert.NoError(t, err)
+
+	name, err := s.Select("name").From("dbr_people").Where("id = ?", 1).ReturnString()
+	assert.NoError(t, err)
+	assert.Equal(t, name, "Jon")
+
+	// ToSql doesn't run the hooks, and a record passed by value is hooked on a copy
+	value := hookedPerson{Name: "  Joe  "}
+	b := s.Update("dbr_people").SetRecord(value, "name").Where("id = ?", 1)
+	_, args, err := b.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, args, []interface{}{"  Joe  ", 1})
+
+	_, err = b.Exec()
+	assert.NoError(t, err)
+	name, err = s.Select("name").From("dbr_people").Where("id = ?", 1).ReturnString()
+	assert.NoError(t, err)
+	assert.Equal(t, name, "Joe")
+
+	person = &hookedPerson{Name: "Fail", fail: true}
+	_, err = s.Update("dbr_people").SetRecord(person, "name").Where("id = ?", 1).Exec()
+	assert.EqualError(t, err, "dbr: update dbr_people: no updates")
+}
@@ -3,6 +3,7 @@ package dbr
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -13,10 +14,21 @@ type InsertBuilder struct {
 	*Session
 	runner
 
-	Into string
-	Cols []string
-	Vals [][]interface{}
-	Recs []interface{}
+	Into           string
+	IsIgnore       bool
+	IsReplace      bool
+	IsHighPriority bool
+	IsDelayed      bool
+	Cols           []string
+	Vals           [][]interface{}
+	Recs           []interface{}
+	FromSelect     *SelectBuilder
+
+	Upsert *upsert
+
+	batchCols []insertColumn // the columns ExecBatch derived from all of its records, used by each chunk
+	immutable bool
+	err       error
 }
 
 // InsertInto instantiates a InsertBuilder for the given table
@@ -37,26 +49,65 @@ func (tx *Tx) InsertInto(into string) *InsertBuilder {
 	}
 }
 
+// Clone returns a copy of the builder that can be extended without affecting the original.
+// Records and the select of INSERT ... SELECT are shared with the original since they belong to the caller.
+func (b *InsertBuilder) Clone() *InsertBuilder {
+	c := *b
+	c.Cols = cloneStrings(b.Cols)
+	if b.Vals != nil {
+		c.Vals = make([][]interface{}, len(b.Vals))
+		for i, row := range b.Vals {
+			c.Vals[i] = cloneArgs(row)
+		}
+	}
+	if b.Recs != nil {
+		c.Recs = append([]interface{}(nil), b.Recs...)
+	}
+	if b.Upsert != nil {
+		c.Upsert = b.Upsert.clone()
+	}
+	return &c
+}
+
+// Immutable returns a copy of the builder on which every chained call returns a modified copy
+// instead of changing the builder itself
+func (b *InsertBuilder) Immutable() *InsertBuilder {
+	c := b.Clone()
+	c.immutable = true
+	return c
+}
+
+func (b *InsertBuilder) mutable() *InsertBuilder {
+	if b.immutable {
+		return b.Clone()
+	}
+	return b
+}
+
 // Columns appends columns to insert in the statement
 func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b = b.mutable()
 	b.Cols = columns
 	return b
 }
 
 // Values appends a set of values to the statement
 func (b *InsertBuilder) Values(vals ...interface{}) *InsertBuilder {
+	b = b.mutable()
 	b.Vals = append(b.Vals, vals)
 	return b
 }
 
 // Record pulls in values to match Columns from the record
 func (b *InsertBuilder) Record(record interface{}) *InsertBuilder {
+	b = b.mutable()
 	b.Recs = append(b.Recs, record)
 	return b
 }
 
 // Pair adds a key/value pair to the statement
 func (b *InsertBuilder) Pair(column string, value interface{}) *InsertBuilder {
+	b = b.mutable()
 	b.Cols = append(b.Cols, column)
 	lenVals := len(b.Vals)
 	if lenVals == 0 {
@@ -65,35 +116,140 @@ func (b *InsertBuilder) Pair(column string, value interface{}) *InsertBuilder {
 	} else if lenVals == 1 {
 		b.Vals[0] = append(b.Vals[0], value)
 	} else {
-		panic("pair only allows you to specify 1 record to insret")
+		b.recordError(errors.New("pair only allows you to specify 1 record to insert"))
 	}
 	return b
 }
 
+// Ignore makes the statement INSERT IGNORE, skipping rows that conflict with an existing unique key
+// and turning other row errors into warnings
+func (b *InsertBuilder) Ignore() *InsertBuilder {
+	b = b.mutable()
+	b.IsIgnore = true
+	return b
+}
+
+// Replace makes the statement REPLACE INTO, deleting any existing row that conflicts with a unique key
+// before inserting the new one
+func (b *InsertBuilder) Replace() *InsertBuilder {
+	b = b.mutable()
+	b.IsReplace = true
+	return b
+}
+
+// HighPriority marks the statement as HIGH_PRIORITY, which only affects tables using table-level locking
+func (b *InsertBuilder) HighPriority() *InsertBuilder {
+	b = b.mutable()
+	b.IsHighPriority = true
+	return b
+}
+
+// Delayed marks the statement as DELAYED. MySQL 5.7 and later accept it but insert normally.
+// It can't be combined with HighPriority, an upsert or Select.
+func (b *InsertBuilder) Delayed() *InsertBuilder {
+	b = b.mutable()
+	b.IsDelayed = true
+	return b
+}
+
+// Select makes the statement INSERT ... SELECT, inserting the rows selected by sb instead of values or records.
+// Columns is optional; without it the select must return a value for every column of the table.
+func (b *InsertBuilder) Select(sb *SelectBuilder) *InsertBuilder {
+	b = b.mutable()
+	b.FromSelect = sb
+	return b
+}
+
+// recordError records err, the first error building the statement, to be returned by ToSql and Exec
+func (b *InsertBuilder) recordError(err error) {
+	err = b.buildError(err)
+	if b.err == nil {
+		b.err = err
+	}
+}
+
 // ToSql serialized the InsertBuilder to a SQL string
-// It returns the string with placeholders and a slice of query arguments
-func (b *InsertBuilder) ToSql() (string, []interface{}) {
+// It returns the string with placeholders and a slice of query arguments, or an error if the statement is invalid
+// BeforeInsert hooks aren't called, only Exec and ExecBatch call them
+func (b *InsertBuilder) ToSql() (string, []interface{}, error) {
+	return b.toSql()
+}
+
+func (b *InsertBuilder) toSql() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
 	if len(b.Into) == 0 {
-		panic("no table specified")
+		return "", nil, b.buildError(errors.New("no table specified"))
 	}
-	if len(b.Cols) == 0 {
-		panic("no columns specified")
+	if b.FromSelect != nil {
+		if len(b.Vals) > 0 || len(b.Recs) > 0 {
+			return "", nil, b.buildError(errors.New("can't insert both values or records and a select"))
+		}
+	} else {
+		// Without Columns, they're derived from the records, which can't be mixed with Values rows
+		if len(b.Cols) == 0 && (len(b.Recs) == 0 || len(b.Vals) > 0) {
+			return "", nil, b.buildError(errors.New("no columns specified"))
+		}
+		if len(b.Vals) == 0 && len(b.Recs) == 0 {
+			return "", nil, b.buildError(errors.New("no values or records specified"))
+		}
 	}
-	if len(b.Vals) == 0 && len(b.Recs) == 0 {
-		panic("no values or records specified")
+	if err := Quoter.checkInsertModifiers(b); err != nil {
+		return "", nil, b.buildError(err)
 	}
 
 	var sql bytes.Buffer
 	var placeholder bytes.Buffer // Build the placeholder like "(?,?,?)"
 	var args []interface{}
 
-	sql.WriteString("INSERT INTO ")
+	if b.IsReplace {
+		sql.WriteString("REPLACE ")
+	} else {
+		sql.WriteString("INSERT ")
+	}
+	if b.IsHighPriority {
+		sql.WriteString("HIGH_PRIORITY ")
+	}
+	if b.IsDelayed {
+		sql.WriteString("DELAYED ")
+	}
+	if b.IsIgnore {
+		sql.WriteString("IGNORE ")
+	}
+	sql.WriteString("INTO ")
 	sql.WriteString(b.Into)
+
+	if b.FromSelect != nil {
+		if err := b.writeSelectToSql(&sql, &args); err != nil {
+			return "", nil, b.buildError(err)
+		}
+		if err := b.writeUpsertToSql(b.Cols, &sql, &args); err != nil {
+			return "", nil, b.buildError(err)
+		}
+		return sql.String(), args, nil
+	}
+
+	cols := b.Cols
+	derived := b.batchCols
+	if len(cols) == 0 {
+		if derived == nil {
+			var err error
+			if derived, err = b.recordColumns(); err != nil {
+				return "", nil, b.buildError(err)
+			}
+		}
+		cols = make([]string, len(derived))
+		for i, col := range derived {
+			cols[i] = col.Name
+		}
+	}
+
 	sql.WriteString(" (")
 
 	// Simulataneously write the cols to the sql buffer, and build a placeholder
 	placeholder.WriteRune('(')
-	for i, c := range b.Cols {
+	for i, c := range cols {
 		if i > 0 {
 			sql.WriteRune(',')
 			placeholder.WriteRune(',')
@@ -123,29 +279,127 @@ func (b *InsertBuilder) ToSql() (string, []interface{}) {
 		if i > 0 || anyVals {
 			sql.WriteRune(',')
 		}
-		sql.WriteString(placeholderStr)
 
 		ind := reflect.Indirect(reflect.ValueOf(rec))
-		vals, err := b.valuesFor(ind.Type(), ind, b.Cols)
+		vals, err := b.valuesFor(ind.Type(), ind, cols)
 		if err != nil {
-			panic(err.Error())
+			return "", nil, b.buildError(err)
 		}
-		for _, v := range vals {
-			args = append(args, v)
+		if derived == nil {
+			sql.WriteString(placeholderStr)
+			args = append(args, vals...)
+			continue
+		}
+
+		// Omittable columns that are zero in this record, but not all of them, get their default
+		sql.WriteRune('(')
+		for j, v := range vals {
+			if j > 0 {
+				sql.WriteRune(',')
+			}
+			if derived[j].Omittable && isZeroField(ind, derived[j].Idxs) {
+				sql.WriteString("DEFAULT")
+			} else {
+				sql.WriteRune('?')
+				args = append(args, v)
+			}
 		}
+		sql.WriteRune(')')
 	}
 
-	return sql.String(), args
+	if err := b.writeUpsertToSql(cols, &sql, &args); err != nil {
+		return "", nil, b.buildError(err)
+	}
+
+	return sql.String(), args, nil
+}
+
+// writeSelectToSql writes the optional column list and the SELECT of an INSERT ... SELECT
+func (b *InsertBuilder) writeSelectToSql(sql *bytes.Buffer, args *[]interface{}) error {
+	if len(b.Cols) > 0 {
+		sql.WriteString(" (")
+		for i, c := range b.Cols {
+			if i > 0 {
+				sql.WriteRune(',')
+			}
+			Quoter.writeQuotedColumn(c, sql)
+		}
+		sql.WriteRune(')')
+	}
+
+	selectSql, selectArgs, err := b.FromSelect.ToSql()
+	if err != nil {
+		return err
+	}
+	sql.WriteRune(' ')
+	sql.WriteString(selectSql)
+	*args = append(*args, selectArgs...)
+	return nil
+}
+
+// recordColumns derives the columns to insert from the records when Columns wasn't called: the mapped fields of
+// the records' type, without the omittable ones (auto-increment keys and omitempty fields) that are zero in every record
+func (b *InsertBuilder) recordColumns() ([]insertColumn, error) {
+	values := make([]reflect.Value, len(b.Recs))
+	for i, rec := range b.Recs {
+		values[i] = reflect.Indirect(reflect.ValueOf(rec))
+		if values[i].Kind() != reflect.Struct {
+			return nil, errors.New("no columns specified")
+		}
+		if values[i].Type() != values[0].Type() {
+			return nil, errors.New("records of different types need columns specified")
+		}
+	}
+
+	var columns []insertColumn
+	for _, col := range insertColumnsFor(values[0].Type()) {
+		if col.Omittable && allZeroFields(values, col.Idxs) {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("no columns specified")
+	}
+	return columns, nil
 }
 
 // Exec executes the statement represented by the InsertBuilder
 // It returns the raw database/sql Result and an error if there was one
 func (b *InsertBuilder) Exec() (sql.Result, error) {
-	sql, args := b.ToSql()
+	if b.err != nil {
+		return nil, b.errorKv("dbr.insert.exec.to_sql", "", b.err, nil)
+	}
+	hooked, err := b.withBeforeInsert()
+	if err != nil {
+		return nil, b.errorKv("dbr.insert.exec.before_insert", "", err, nil)
+	}
+	sql, args, err := hooked.toSql()
+	if err != nil {
+		return nil, b.errorKv("dbr.insert.exec.to_sql", sql, err, nil)
+	}
+	return hooked.exec(sql, args)
+}
 
+// withBeforeInsert runs the BeforeInsert hooks of the records, and returns the builder to build the statement from
+func (b *InsertBuilder) withBeforeInsert() (*InsertBuilder, error) {
+	recs, err := beforeInsert(b.Recs)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 || &recs[0] == &b.Recs[0] {
+		return b, nil
+	}
+	hooked := *b
+	hooked.Recs = recs
+	return &hooked, nil
+}
+
+// exec runs the statement sql with args, which were built from the builder
+func (b *InsertBuilder) exec(sql string, args []interface{}) (sql.Result, error) {
 	fullSql, err := Interpolate(sql, args)
 	if err != nil {
-		return nil, b.EventErrKv("dbr.insert.exec.interpolate", err, kvs{"sql": sql, "args": fmt.Sprint(args)})
+		return nil, b.errorKv("dbr.insert.exec.interpolate", sql, err, kvs{"sql": sql, "args": fmt.Sprint(args)})
 	}
 
 	// Start the timer:
@@ -154,7 +408,7 @@ func (b *InsertBuilder) Exec() (sql.Result, error) {
 
 	result, err := b.runner.Exec(fullSql)
 	if err != nil {
-		return result, b.EventErrKv("dbr.insert.exec.exec", err, kvs{"sql": fullSql})
+		return result, b.errorKv("dbr.insert.exec.exec", sql, err, kvs{"sql": fullSql})
 	}
 
 	// If the structure has an "Id" field which is an int64, set it from the LastInsertId(). Otherwise, don't bother.
@@ -162,11 +416,17 @@ func (b *InsertBuilder) Exec() (sql.Result, error) {
 		rec := b.Recs[0]
 		val := reflect.Indirect(reflect.ValueOf(rec))
 		if val.Kind() == reflect.Struct && val.CanSet() {
-			if idField := val.FieldByName("Id"); idField.IsValid() && idField.Kind() == reflect.Int64 {
-				if lastID, err := result.LastInsertId(); err == nil {
-					idField.Set(reflect.ValueOf(lastID))
-				} else {
-					b.EventErrKv("dbr.insert.exec.last_inserted_id", err, kvs{"sql": fullSql})
+			if idStruct, ok := val.Type().FieldByName("Id"); ok && idStruct.Type.Kind() == reflect.Int64 {
+				// The Id may be promoted from a nil pointer to an embedded struct, in which case there's nowhere to put it.
+				// The id reported for a row an upsert updated or INSERT IGNORE skipped isn't reliable,
+				// so those keep an Id the record already has.
+				idField, ok := fieldByIndexNoAlloc(val, idStruct.Index)
+				if ok && (b.Upsert == nil && !b.IsIgnore || idField.Int() == 0) {
+					if lastID, err := result.LastInsertId(); err == nil {
+						idField.Set(reflect.ValueOf(lastID))
+					} else {
+						b.EventErrKv("dbr.insert.exec.last_inserted_id", err, kvs{"sql": fullSql})
+					}
 				}
 			}
 		}
@@ -174,3 +434,13 @@ func (b *InsertBuilder) Exec() (sql.Result, error) {
 
 	return result, nil
 }
+
+// errorKv reports err to the EventReceiver and returns it wrapped in an *Error with sql, the statement built so far, if any
+func (b *InsertBuilder) errorKv(eventName, sql string, err error, kvs kvs) error {
+	if kvs == nil {
+		err = b.EventErr(eventName, err)
+	} else {
+		err = b.EventErrKv(eventName, err, kvs)
+	}
+	return newError("insert", b.Into, sql, err)
+}
@@ -0,0 +1,219 @@
+package dbr
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatchOptions controls how ExecBatch splits the rows of an InsertBuilder into statements
+type BatchOptions struct {
+	// MaxRows is the most rows to insert per statement, or 0 for no limit
+	MaxRows int
+
+	// MaxBytes is the most bytes per statement, or 0 for no limit. Set it below the server's max_allowed_packet.
+	// Statement sizes are estimated from the interpolated values, so leave some headroom.
+	MaxBytes int
+
+	// Transaction runs all the statements in a transaction, unless the builder is already bound to one
+	Transaction bool
+}
+
+// ExecBatch inserts the Values rows and Records of the builder with a statement per chunk of rows, run one after another,
+// and returns the total rows affected. A row that doesn't fit in MaxBytes on its own is inserted by itself.
+// Without Columns, every chunk inserts the columns derived from all of the records.
+// If a chunk fails, ExecBatch stops and returns the rows affected by the chunks before it, or 0 if it rolled back a transaction.
+func (b *InsertBuilder) ExecBatch(opts BatchOptions) (int64, error) {
+	if b.err != nil {
+		return 0, b.errorKv("dbr.insert.batch.to_sql", "", b.err, nil)
+	}
+	if b.FromSelect != nil {
+		return 0, b.errorKv("dbr.insert.batch.to_sql", "", b.buildError(errors.New("can't batch an insert from a select")), nil)
+	}
+	if len(b.Vals) == 0 && len(b.Recs) == 0 {
+		return 0, b.errorKv("dbr.insert.batch.to_sql", "", b.buildError(errors.New("no values or records specified")), nil)
+	}
+
+	// Run the hooks up front, since they can change the size of the records
+	hooked, err := b.withBeforeInsert()
+	if err != nil {
+		return 0, b.errorKv("dbr.insert.batch.before_insert", "", err, nil)
+	}
+
+	chunks, err := hooked.chunks(opts)
+	if err != nil {
+		return 0, b.errorKv("dbr.insert.batch.to_sql", "", err, nil)
+	}
+
+	startTime := time.Now()
+	defer func() {
+		b.TimingKv("dbr.insert.batch", time.Since(startTime).Nanoseconds(), kvs{"chunks": strconv.Itoa(len(chunks))})
+	}()
+
+	runner := b.runner
+	var tx *Tx
+	if _, inTx := runner.(*sql.Tx); opts.Transaction && !inTx {
+		if tx, err = b.Begin(); err != nil {
+			return 0, err
+		}
+		defer tx.RollbackUnlessCommitted()
+		runner = tx.Tx
+	}
+
+	var total int64
+	for i, chunk := range chunks {
+		chunk.runner = runner
+
+		chunkStart := time.Now()
+		sql, args, err := chunk.toSql()
+		if err != nil {
+			return rowsUnlessRolledBack(tx, total), chunk.errorKv("dbr.insert.batch.to_sql", sql, err, nil)
+		}
+		result, err := chunk.exec(sql, args)
+		if err != nil {
+			return rowsUnlessRolledBack(tx, total), err
+		}
+		rowsAff, err := result.RowsAffected()
+		if err != nil {
+			return rowsUnlessRolledBack(tx, total), chunk.errorKv("dbr.insert.batch.rows_affected", sql, err, nil)
+		}
+		total += rowsAff
+
+		b.TimingKv("dbr.insert.batch.chunk", time.Since(chunkStart).Nanoseconds(), kvs{
+			"chunk": strconv.Itoa(i),
+			"rows":  strconv.Itoa(len(chunk.Vals) + len(chunk.Recs)),
+		})
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+func rowsUnlessRolledBack(tx *Tx, rows int64) int64 {
+	if tx != nil {
+		return 0
+	}
+	return rows
+}
+
+// chunks splits the rows of the builder into builders that each insert a chunk of them within the limits of opts
+func (b *InsertBuilder) chunks(opts BatchOptions) ([]*InsertBuilder, error) {
+	// Derive the columns from all the records, so that every chunk inserts the same columns
+	if len(b.Cols) == 0 && len(b.Vals) == 0 {
+		derived, err := b.recordColumns()
+		if err != nil {
+			return nil, b.buildError(err)
+		}
+		batch := *b
+		batch.batchCols = derived
+		b = &batch
+	}
+
+	var sizes []int
+	overhead := 0
+	if opts.MaxBytes > 0 {
+		var err error
+		if sizes, overhead, err = b.rowSizes(); err != nil {
+			return nil, err
+		}
+	}
+
+	newChunk := func() *InsertBuilder {
+		c := *b
+		c.Vals, c.Recs = nil, nil
+		c.immutable = false
+		return &c
+	}
+
+	var chunks []*InsertBuilder
+	chunk := newChunk()
+	rows, size := 0, overhead
+	for i := 0; i < len(b.Vals)+len(b.Recs); i++ {
+		if rows > 0 {
+			full := opts.MaxRows > 0 && rows >= opts.MaxRows
+			full = full || opts.MaxBytes > 0 && size+1+sizes[i] > opts.MaxBytes
+			if full {
+				chunks = append(chunks, chunk)
+				chunk = newChunk()
+				rows, size = 0, overhead
+			}
+		}
+
+		if i < len(b.Vals) {
+			chunk.Vals = append(chunk.Vals, b.Vals[i])
+		} else {
+			chunk.Recs = append(chunk.Recs, b.Recs[i-len(b.Vals)])
+		}
+		if rows > 0 {
+			size++ // the comma between rows
+		}
+		if sizes != nil {
+			size += sizes[i]
+		}
+		rows++
+	}
+	return append(chunks, chunk), nil
+}
+
+// rowSizes estimates the size of each row's interpolated values, and of the rest of a statement
+func (b *InsertBuilder) rowSizes() ([]int, int, error) {
+	cols := b.Cols
+	for _, col := range b.batchCols {
+		cols = append(cols, col.Name)
+	}
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",") + ")"
+
+	sizes := make([]int, 0, len(b.Vals)+len(b.Recs))
+	rowSize := func(vals []interface{}) error {
+		row, err := Interpolate(placeholder, vals)
+		if err != nil {
+			return err
+		}
+		sizes = append(sizes, len(row))
+		return nil
+	}
+	for _, row := range b.Vals {
+		if err := rowSize(row); err != nil {
+			return nil, 0, b.buildError(err)
+		}
+	}
+	for _, rec := range b.Recs {
+		ind := reflect.Indirect(reflect.ValueOf(rec))
+		vals, err := b.valuesFor(ind.Type(), ind, cols)
+		if err != nil {
+			return nil, 0, b.buildError(err)
+		}
+		if err := rowSize(vals); err != nil {
+			return nil, 0, b.buildError(err)
+		}
+	}
+
+	// The rest of the statement is what a single row statement has besides the row
+	single := b.Clone()
+	if len(b.Vals) > 0 {
+		single.Vals, single.Recs = b.Vals[:1], nil
+	} else {
+		single.Vals, single.Recs = nil, b.Recs[:1]
+	}
+	sql, args, err := single.toSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	fullSql, err := Interpolate(sql, args)
+	if err != nil {
+		return nil, 0, b.buildError(err)
+	}
+
+	overhead := len(fullSql) - sizes[0]
+	if overhead < 0 {
+		overhead = 0
+	}
+	return sizes, overhead, nil
+}
@@ -0,0 +1,115 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chunkReceiver records the rows and statement of each chunk ExecBatch reports
+type chunkReceiver struct {
+	NullEventReceiver
+	chunkRows []string
+	chunkSqls []string
+}
+
+func (r *chunkReceiver) TimingKv(eventName string, nanoseconds int64, kvs map[string]string) {
+	switch eventName {
+	case "dbr.insert.batch.chunk":
+		r.chunkRows = append(r.chunkRows, kvs["rows"])
+	case "dbr.insert":
+		r.chunkSqls = append(r.chunkSqls, kvs["sql"])
+	}
+}
+
+func TestInsertExecBatchRows(t *testing.T) {
+	s := createRealSessionWithFixtures()
+	rec := &chunkReceiver{}
+	s.EventReceiver = rec
+
+	b := s.InsertInto("dbr_people").Columns("name", "email")
+	for i := 0; i < 3; i++ {
+		b.Values("Barack", "obama@whitehouse.gov")
+	}
+	b.Record(&dbrPerson{Name: "Joe"}).Record(&dbrPerson{Name: "Kamala"})
+
+	rowsAff, err := b.ExecBatch(BatchOptions{MaxRows: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, rowsAff, int64(5))
+	assert.Equal(t, rec.chunkRows, []string{"2", "2", "1"})
+
+	count, err := s.Select("COUNT(*)").From("dbr_people").ReturnInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(7))
+}
+
+func TestInsertExecBatchRecordColumns(t *testing.T) {
+	s := createRealSessionWithFixtures()
+	rec := &chunkReceiver{}
+	s.EventReceiver = rec
+
+	// The id is derived for the whole batch, so the chunk without one inserts its default
+	rowsAff, err := s.InsertInto("dbr_people").
+		Record(&dbrPerson{Name: "Joe"}).Record(&dbrPerson{Id: 10, Name: "Kamala"}).
+		ExecBatch(BatchOptions{MaxRows: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, rowsAff, int64(2))
+	assert.Equal(t, rec.chunkSqls, []string{
+		"INSERT INTO dbr_people (`id`,`name`,`email`,`key`) VALUES (DEFAULT,'Joe',NULL,NULL)",
+		"INSERT INTO dbr_people (`id`,`name`,`email`,`key`) VALUES (10,'Kamala',NULL,NULL)",
+	})
+}
+
+func TestInsertExecBatchBytes(t *testing.T) {
+	s := createRealSessionWithFixtures()
+	rec := &chunkReceiver{}
+	s.EventReceiver = rec
+
+	b := s.InsertInto("dbr_people").Columns("name")
+	for _, name := range []string{"Barack", "Joe", "Kamala", "Hillary"} {
+		b.Values(name)
+	}
+
+	// Enough for the statement with two of the rows, but not three
+	sql, args, err := s.InsertInto("dbr_people").Columns("name").Values("Barack").Values("Joe").ToSql()
+	assert.NoError(t, err)
+	fullSql, err := Interpolate(sql, args)
+	assert.NoError(t, err)
+
+	rowsAff, err := b.ExecBatch(BatchOptions{MaxBytes: len(fullSql)})
+	assert.NoError(t, err)
+	assert.Equal(t, rowsAff, int64(4))
+	assert.Equal(t, rec.chunkRows, []string{"2", "1", "1"})
+
+	// A row that's too large on its own still goes in a chunk of its own
+	rec.chunkRows = nil
+	rowsAff, err = s.InsertInto("dbr_people").Columns("name").Values("Joe").Values("Joe").ExecBatch(BatchOptions{MaxBytes: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, rowsAff, int64(2))
+	assert.Equal(t, rec.chunkRows, []string{"1", "1"})
+}
+
+func TestInsertExecBatchTransaction(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// The second chunk fails on the duplicate primary key, rolling back the first
+	rowsAff, err := s.InsertInto("dbr_people").Columns("id", "name").
+		Values(10, "Barack").Values(11, "Joe").Values(1, "Jonathan").
+		ExecBatch(BatchOptions{MaxRows: 2, Transaction: true})
+	assert.True(t, IsDuplicateKey(err))
+	assert.Equal(t, rowsAff, int64(0))
+
+	count, err := s.Select("COUNT(*)").From("dbr_people").ReturnInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(2))
+
+	// Without a transaction the first chunk stays
+	rowsAff, err = s.InsertInto("dbr_people").Columns("id", "name").
+		Values(10, "Barack").Values(11, "Joe").Values(1, "Jonathan").
+		ExecBatch(BatchOptions{MaxRows: 2})
+	assert.True(t, IsDuplicateKey(err))
+	assert.Equal(t, rowsAff, int64(2))
+
+	_, err = s.InsertInto("dbr_people").Columns("name").Select(s.Select("name").From("dbr_people")).ExecBatch(BatchOptions{MaxRows: 2})
+	assert.EqualError(t, err, "dbr: insert dbr_people: can't batch an insert from a select")
+}
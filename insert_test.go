@@ -34,10 +34,25 @@ func BenchmarkInsertRecordsSql(b *testing.B) {
 	}
 }
 
+func BenchmarkInsertManyRecordsSql(b *testing.B) {
+	s := createFakeSession()
+	builder := s.InsertInto("alpha").Columns("something_id", "user_id", "other")
+	for i := 0; i < 100; i++ {
+		builder.Record(someRecord{i, 99, false})
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		builder.ToSql()
+	}
+}
+
 func TestInsertSingleToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.InsertInto("a").Columns("b", "c").Values(1, 2).ToSql()
+	sql, args, err := s.InsertInto("a").Columns("b", "c").Values(1, 2).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?)")
 	assert.Equal(t, args, []interface{}{1, 2})
@@ -46,7 +61,8 @@ func TestInsertSingleToSql(t *testing.T) {
 func TestInsertMultipleToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.InsertInto("a").Columns("b", "c").Values(1, 2).Values(3, 4).ToSql()
+	sql, args, err := s.InsertInto("a").Columns("b", "c").Values(1, 2).Values(3, 4).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?),(?,?)")
 	assert.Equal(t, args, []interface{}{1, 2, 3, 4})
@@ -56,12 +72,284 @@ func TestInsertRecordsToSql(t *testing.T) {
 	s := createFakeSession()
 
 	objs := []someRecord{{1, 88, false}, {2, 99, true}}
-	sql, args := s.InsertInto("a").Columns("something_id", "user_id", "other").Record(objs[0]).Record(objs[1]).ToSql()
+	sql, args, err := s.InsertInto("a").Columns("something_id", "user_id", "other").Record(objs[0]).Record(objs[1]).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "INSERT INTO a (`something_id`,`user_id`,`other`) VALUES (?,?,?),(?,?,?)")
 	assert.Equal(t, args, []interface{}{1, 88, false, 2, 99, true})
 }
 
+func TestInsertClone(t *testing.T) {
+	s := createFakeSession()
+
+	base := s.InsertInto("a").Pair("b", 1)
+	clone := base.Clone().Pair("c", 2)
+
+	sql, args, err := base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`) VALUES (?)")
+	assert.Equal(t, args, []interface{}{1})
+
+	sql, args, err = clone.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?)")
+	assert.Equal(t, args, []interface{}{1, 2})
+}
+
+func TestInsertBuildErrors(t *testing.T) {
+	s := createFakeSession()
+
+	_, _, err := s.InsertInto("a").Columns("b").Values(1).Values(2).Pair("c", 3).ToSql()
+	assert.EqualError(t, err, "pair only allows you to specify 1 record to insert")
+
+	_, _, err = s.InsertInto("a").Columns("b").ToSql()
+	assert.EqualError(t, err, "no values or records specified")
+
+	_, err = s.InsertInto("a").Columns("wat").Record(someRecord{}).Exec()
+	assert.EqualError(t, err, "dbr: insert a: couldn't find match for column wat")
+}
+
+func TestInsertUpsertToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.InsertInto("a").Columns("b", "c", "d").Values(1, 2, 3).OnDuplicateKeyUpdate("c", "d").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`,`d`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `c` = VALUES(`c`), `d` = VALUES(`d`)")
+	assert.Equal(t, args, []interface{}{1, 2, 3})
+
+	sql, args, err = s.InsertInto("a").Columns("b", "c").Values(1, 2).
+		OnDuplicateKeyUpdateMap(map[string]interface{}{"hits": Expr("hits + ?", 1), "c": Excluded("c"), "d": 5}).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?) ON DUPLICATE KEY UPDATE `c` = VALUES(`c`), `d` = ?, `hits` = hits + ?")
+	assert.Equal(t, args, []interface{}{1, 2, 5, 1})
+
+	objs := []someRecord{{1, 88, false}, {2, 99, true}}
+	sql, args, err = s.InsertInto("a").Columns("something_id", "user_id", "other").Record(objs[0]).Record(objs[1]).
+		OnConflict("something_id").DoUpdate("other").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`something_id`,`user_id`,`other`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `other` = VALUES(`other`)")
+	assert.Equal(t, args, []interface{}{1, int64(88), false, 2, int64(99), true})
+
+	sql, _, err = s.InsertInto("a").Columns("b", "c").Values(1, 2).OnConflict("c").DoNothing().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?) ON DUPLICATE KEY UPDATE `c` = `c`")
+
+	sql, _, err = s.InsertInto("a").Columns("b", "c").Values(1, 2).OnConflict().DoNothing().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?) ON DUPLICATE KEY UPDATE `b` = `b`")
+
+	base := s.InsertInto("a").Columns("b", "c").Values(1, 2).OnDuplicateKeyUpdate("b").Immutable()
+	base.OnDuplicateKeyUpdate("c")
+	sql, _, err = base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) VALUES (?,?) ON DUPLICATE KEY UPDATE `b` = VALUES(`b`)")
+
+	b := s.InsertInto("a").Columns("b").Values(1)
+	b.OnConflict("b")
+	_, _, err = b.ToSql()
+	assert.EqualError(t, err, "on conflict needs DoNothing or DoUpdate")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).OnDuplicateKeyUpdate().ToSql()
+	assert.EqualError(t, err, "no columns to update specified")
+}
+
+func TestInsertUpsertReal(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// Conflicts with the fixture row with id 1
+	person := dbrPerson{Id: 1, Name: "Jon"}
+	_, err := s.InsertInto("dbr_people").Columns("id", "name", "email").Record(&person).OnDuplicateKeyUpdate("name").Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, person.Id, int64(1))
+
+	_, err = s.InsertInto("dbr_people").Columns("id", "name", "email").Values(2, "Dmitri", nil).Values(3, "Barack", "obama@whitehouse.gov").
+		OnDuplicateKeyUpdate("name").Exec()
+	assert.NoError(t, err)
+
+	var people []*dbrPerson
+	_, err = s.Select("*").From("dbr_people").OrderBy("id").LoadStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, len(people), 3)
+	assert.Equal(t, people[0].Name, "Jon")
+	assert.Equal(t, people[0].Email.String, "jonathan@uservoice.com")
+	assert.Equal(t, people[2].Name, "Barack")
+
+	_, err = s.InsertInto("dbr_people").Columns("id", "name").Values(2, "Dima").
+		OnDuplicateKeyUpdateMap(map[string]interface{}{"name": Expr("CONCAT(name, ?)", "!")}).Exec()
+	assert.NoError(t, err)
+
+	_, err = s.InsertInto("dbr_people").Columns("id", "name").Values(3, "Joe").OnConflict("id").DoNothing().Exec()
+	assert.NoError(t, err)
+
+	var names []string
+	_, err = s.Select("name").From("dbr_people").OrderBy("id").LoadValues(&names)
+	assert.NoError(t, err)
+	assert.Equal(t, names, []string{"Jon", "Dmitri!", "Barack"})
+}
+
+func TestInsertModifiersToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, _, err := s.InsertInto("a").Columns("b").Values(1).Ignore().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT IGNORE INTO a (`b`) VALUES (?)")
+
+	sql, _, err = s.InsertInto("a").Columns("b").Values(1).Replace().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "REPLACE INTO a (`b`) VALUES (?)")
+
+	sql, _, err = s.InsertInto("a").Columns("b").Values(1).Replace().Delayed().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "REPLACE DELAYED INTO a (`b`) VALUES (?)")
+
+	sql, _, err = s.InsertInto("a").Columns("b").Values(1).HighPriority().Ignore().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT HIGH_PRIORITY IGNORE INTO a (`b`) VALUES (?)")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).HighPriority().Delayed().ToSql()
+	assert.EqualError(t, err, "insert can't be both HIGH_PRIORITY and DELAYED")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).Replace().Ignore().ToSql()
+	assert.EqualError(t, err, "replace doesn't support IGNORE")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).Replace().HighPriority().ToSql()
+	assert.EqualError(t, err, "replace doesn't support HIGH_PRIORITY")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).Replace().OnDuplicateKeyUpdate("b").ToSql()
+	assert.EqualError(t, err, "replace doesn't support ON DUPLICATE KEY UPDATE")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).Delayed().OnDuplicateKeyUpdate("b").ToSql()
+	assert.EqualError(t, err, "DELAYED doesn't support ON DUPLICATE KEY UPDATE")
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).Delayed().OnConflict("b").DoNothing().ToSql()
+	assert.EqualError(t, err, "DELAYED doesn't support ON DUPLICATE KEY UPDATE")
+
+	_, _, err = s.InsertInto("a").Columns("b").Select(s.Select("b").From("c")).Delayed().ToSql()
+	assert.EqualError(t, err, "DELAYED doesn't support inserting from a select")
+}
+
+func TestInsertIgnoreAndReplaceReal(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	person := dbrPerson{Id: 1, Name: "Jon"}
+	res, err := s.InsertInto("dbr_people").Columns("id", "name").Record(&person).Ignore().Exec()
+	assert.NoError(t, err)
+	rowsAff, err := res.RowsAffected()
+	assert.NoError(t, err)
+	assert.Equal(t, rowsAff, int64(0))
+	assert.Equal(t, person.Id, int64(1))
+
+	_, err = s.InsertInto("dbr_people").Columns("id", "name").Values(2, "Dima").Replace().Exec()
+	assert.NoError(t, err)
+
+	var people []*dbrPerson
+	_, err = s.Select("*").From("dbr_people").OrderBy("id").LoadStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, len(people), 2)
+	assert.Equal(t, people[0].Name, "Jonathan")
+	assert.Equal(t, people[1].Name, "Dima")
+	assert.Equal(t, people[1].Email.Valid, false)
+}
+
+func TestInsertSelectToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sb := s.Select("b", "c").From("d").Where("e = ?", 1)
+	sql, args, err := s.InsertInto("a").Columns("b", "c").Select(sb).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`,`c`) SELECT b, c FROM d WHERE (e = ?)")
+	assert.Equal(t, args, []interface{}{1})
+
+	sql, args, err = s.InsertInto("a").Select(s.Select("*").From("d")).Ignore().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT IGNORE INTO a SELECT * FROM d")
+	assert.Equal(t, len(args), 0)
+
+	sql, args, err = s.InsertInto("a").Columns("b").Select(sb).OnDuplicateKeyUpdate("b").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`b`) SELECT b, c FROM d WHERE (e = ?) ON DUPLICATE KEY UPDATE `b` = VALUES(`b`)")
+	assert.Equal(t, args, []interface{}{1})
+
+	_, _, err = s.InsertInto("a").Columns("b").Values(1).Select(sb).ToSql()
+	assert.EqualError(t, err, "can't insert both values or records and a select")
+
+	_, _, err = s.InsertInto("a").Select(s.Select("b")).ToSql()
+	assert.EqualError(t, err, "no table specified")
+}
+
+func TestInsertSelectReal(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	res, err := s.InsertInto("dbr_people").Columns("name", "email").
+		Select(s.Select("name", "email").From("dbr_people").Where("name = ?", "Jonathan")).Exec()
+	assert.NoError(t, err)
+	rowsAff, err := res.RowsAffected()
+	assert.NoError(t, err)
+	assert.Equal(t, rowsAff, int64(1))
+
+	count, err := s.Select("COUNT(*)").From("dbr_people").Where("email = ?", "jonathan@uservoice.com").ReturnInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(2))
+}
+
+type autoColumnsBase struct {
+	CreatedBy string `db:"created_by,omitempty"`
+}
+
+type autoColumnsRecord struct {
+	autoColumnsBase
+	Id      int64
+	Name    string
+	Nick    string `db:"nickname,omitempty"`
+	Ignored string `db:"-"`
+	Seq     int    `db:"seq,autoincrement"`
+}
+
+func TestInsertRecordColumnsToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.InsertInto("a").Record(&autoColumnsRecord{Name: "Jon", Ignored: "x"}).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`name`) VALUES (?)")
+	assert.Equal(t, args, []interface{}{"Jon"})
+
+	rec := autoColumnsRecord{autoColumnsBase: autoColumnsBase{CreatedBy: "admin"}, Id: 5, Name: "Jon", Nick: "jj", Seq: 2}
+	sql, args, err = s.InsertInto("a").Record(rec).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`created_by`,`id`,`name`,`nickname`,`seq`) VALUES (?,?,?,?,?)")
+	assert.Equal(t, args, []interface{}{"admin", int64(5), "Jon", "jj", 2})
+
+	// A column that's only set on some records defaults on the others
+	sql, args, err = s.InsertInto("a").Record(&autoColumnsRecord{Name: "Jon"}).Record(&autoColumnsRecord{Name: "Dmitri", Nick: "dima"}).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO a (`name`,`nickname`) VALUES (?,DEFAULT),(?,?)")
+	assert.Equal(t, args, []interface{}{"Jon", "Dmitri", "dima"})
+
+	_, _, err = s.InsertInto("a").Record(&autoColumnsRecord{Name: "Jon"}).Record(&someRecord{}).ToSql()
+	assert.EqualError(t, err, "records of different types need columns specified")
+
+	_, _, err = s.InsertInto("a").Values(1).Record(&autoColumnsRecord{Name: "Jon"}).ToSql()
+	assert.EqualError(t, err, "no columns specified")
+
+	// The fields of named nested structs, prefixed or behind a pointer, belong to joined tables
+	order := prefixOrder{Id: 3, Name: "Order", Customer: prefixCustomer{Id: 1, Name: "Jon"}, Referrer: &prefixCustomer{Id: 2, Name: "Dmitri"}}
+	sql, args, err = s.InsertInto("orders").Record(&order).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO orders (`id`,`name`) VALUES (?,?)")
+	assert.Equal(t, args, []interface{}{int64(3), "Order"})
+}
+
+func TestInsertRecordColumnsReal(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	person := dbrPerson{Name: "Barack"}
+	person.Email.Valid = true
+	person.Email.String = "obama@whitehouse.gov"
+	res, err := s.InsertInto("dbr_people").Record(&person).Exec()
+	validateInsertingBarack(t, s, res, err)
+	assert.True(t, person.Id > 0)
+}
+
 func TestInsertKeywordColumnName(t *testing.T) {
 	// Insert a column whose name is reserved
 	s := createRealSessionWithFixtures()
@@ -101,7 +389,7 @@ func validateInsertingBarack(t *testing.T, s *Session, res sql.Result, err error
 	assert.NoError(t, err)
 
 	assert.True(t, id > 0)
-	assert.Equal(t, rowsAff, 1)
+	assert.Equal(t, rowsAff, int64(1))
 
 	var person dbrPerson
 	err = s.Select("*").From("dbr_people").Where("id = ?", id).LoadStruct(&person)
@@ -0,0 +1,214 @@
+package dbr
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// Relation describes associated records to load for the structs loaded by a SelectBuilder.
+// Each relation is loaded with a single "WHERE key IN (...)" query, using the same Session or Tx.
+// Tag the fields holding associations with db:"-" so they aren't mapped to the columns of the main query.
+type Relation struct {
+	Field      string        // field of the loaded structs to fill
+	Table      string        // table holding the associated records
+	ForeignKey string        // column holding the reference; on Table for HasMany, on the loaded structs for BelongsTo
+	PrimaryKey string        // column being referenced; on the loaded structs for HasMany, on Table for BelongsTo. Defaults to "id"
+	Scopes     []SelectScope // scopes applied to the query for the associated records, eg for ordering
+
+	belongsTo bool
+}
+
+// HasMany describes associated records that refer to the loaded structs, eg comments with a post_id.
+// field must be a slice of structs or of pointers to structs.
+func HasMany(field, table, foreignKey string) *Relation {
+	return &Relation{Field: field, Table: table, ForeignKey: foreignKey, PrimaryKey: "id"}
+}
+
+// BelongsTo describes an associated record that the loaded structs refer to, eg the author of a post with an author_id.
+// field must be a struct or a pointer to a struct; it's left untouched if there's no matching record.
+func BelongsTo(field, table, foreignKey string) *Relation {
+	return &Relation{Field: field, Table: table, ForeignKey: foreignKey, PrimaryKey: "id", belongsTo: true}
+}
+
+// Preload adds relations to load after LoadStructs, LoadStruct, LoadStructsMap or LoadGrouped loads the statement's results
+func (b *SelectBuilder) Preload(relations ...*Relation) *SelectBuilder {
+	b = b.mutable()
+	b.Preloads = append(b.Preloads, relations...)
+	return b
+}
+
+// preload loads b.Preloads into records, which is a slice of structs or of pointers to structs
+func (b *SelectBuilder) preload(records reflect.Value) error {
+	var parents []reflect.Value
+	for i := 0; i < records.Len(); i++ {
+		parents = append(parents, reflect.Indirect(records.Index(i)))
+	}
+	if len(parents) == 0 {
+		return nil
+	}
+
+	for _, rel := range b.Preloads {
+		if err := b.preloadRelation(parents, rel); err != nil {
+			return b.errorKv("dbr.select.preload", "", err, kvs{"table": rel.Table, "field": rel.Field})
+		}
+	}
+	return nil
+}
+
+func (b *SelectBuilder) preloadRelation(parents []reflect.Value, rel *Relation) error {
+	parentType := parents[0].Type()
+	destField, ok := parentType.FieldByName(rel.Field)
+	if !ok {
+		return fmt.Errorf("dbr: preload: %s has no field %s", parentType, rel.Field)
+	}
+
+	// The type of the associated records, and whether the field holds them by pointer
+	childType := destField.Type
+	if !rel.belongsTo {
+		if childType.Kind() != reflect.Slice {
+			return fmt.Errorf("dbr: preload: HasMany field %s must be a slice", rel.Field)
+		}
+		childType = childType.Elem()
+	}
+	childIsPtr := childType.Kind() == reflect.Ptr
+	if childIsPtr {
+		childType = childType.Elem()
+	}
+	if childType.Kind() != reflect.Struct {
+		return fmt.Errorf("dbr: preload: field %s must hold structs", rel.Field)
+	}
+
+	// Which column on each side is the key to join on
+	parentKeyColumn, childKeyColumn := rel.PrimaryKey, rel.ForeignKey
+	if rel.belongsTo {
+		parentKeyColumn, childKeyColumn = rel.ForeignKey, rel.PrimaryKey
+	}
+	parentKeyIndex, ok := fieldIndexFor(parentType)[parentKeyColumn]
+	if !ok {
+		return fmt.Errorf("dbr: preload: %s has no field for column %s", parentType, parentKeyColumn)
+	}
+	childKeyIndex, ok := fieldIndexFor(childType)[childKeyColumn]
+	if !ok {
+		return fmt.Errorf("dbr: preload: %s has no field for column %s", childType, childKeyColumn)
+	}
+
+	// Collect the distinct keys of the parents, skipping NULLs
+	var keys []interface{}
+	parentKeys := make([]interface{}, len(parents))
+	seen := map[interface{}]bool{}
+	for i, parent := range parents {
+		field, ok := fieldByIndexNoAlloc(parent, parentKeyIndex)
+		if !ok {
+			continue
+		}
+		key, err := preloadKey(field.Interface())
+		if err != nil {
+			return err
+		}
+		parentKeys[i] = key
+		if key != nil && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	// Load the associated records
+	children := reflect.New(reflect.SliceOf(reflect.PtrTo(childType)))
+	if len(keys) > 0 {
+		var cond bytes.Buffer
+		Quoter.writeQuotedColumn(childKeyColumn, &cond)
+		cond.WriteString(" IN (")
+		for i := range keys {
+			if i > 0 {
+				cond.WriteRune(',')
+			}
+			cond.WriteRune('?')
+		}
+		cond.WriteRune(')')
+
+		query := &SelectBuilder{Session: b.Session, runner: b.runner, Columns: []interface{}{"*"}, FromTable: rel.Table}
+		if _, err := query.Where(cond.String(), keys...).Scopes(rel.Scopes...).LoadStructs(children.Interface()); err != nil {
+			return err
+		}
+	}
+	children = children.Elem()
+
+	// Group them by key, preserving the order they were loaded in
+	grouped := map[interface{}][]reflect.Value{}
+	for i := 0; i < children.Len(); i++ {
+		child := children.Index(i)
+		field, ok := fieldByIndexNoAlloc(child.Elem(), childKeyIndex)
+		if !ok {
+			continue
+		}
+		key, err := preloadKey(field.Interface())
+		if err != nil {
+			return err
+		}
+		grouped[key] = append(grouped[key], child)
+	}
+
+	// Stitch them into the parents
+	for i, parent := range parents {
+		dest := fieldByIndexAlloc(parent, destField.Index)
+		matches := grouped[parentKeys[i]]
+		if parentKeys[i] == nil {
+			matches = nil
+		}
+
+		if rel.belongsTo {
+			if len(matches) == 0 {
+				continue
+			}
+			if childIsPtr {
+				dest.Set(matches[0])
+			} else {
+				dest.Set(matches[0].Elem())
+			}
+			continue
+		}
+
+		slice := reflect.MakeSlice(destField.Type, 0, len(matches))
+		for _, child := range matches {
+			if childIsPtr {
+				slice = reflect.Append(slice, child)
+			} else {
+				slice = reflect.Append(slice, child.Elem())
+			}
+		}
+		dest.Set(slice)
+	}
+
+	return nil
+}
+
+// preloadKey normalizes a key value so that, eg, an int parent id matches an int64 foreign key.
+// NULL keys are returned as nil.
+func preloadKey(v interface{}) (interface{}, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	val := reflect.ValueOf(v)
+	switch {
+	case isInt(val.Kind()):
+		return val.Int(), nil
+	case isUint(val.Kind()):
+		return int64(val.Uint()), nil
+	case val.Kind() == reflect.String:
+		return val.String(), nil
+	case val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.Uint8:
+		return string(val.Bytes()), nil
+	case val.Type().Comparable():
+		return v, nil
+	}
+	return nil, fmt.Errorf("dbr: preload: can't use %T as a key", v)
+}
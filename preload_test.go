@@ -0,0 +1,163 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dbrAddress struct {
+	Id       int64
+	PersonId NullInt64
+	City     string
+	Person   *dbrPerson `db:"-"`
+}
+
+type dbrPersonWithAddresses struct {
+	dbrPerson
+	Addresses []*dbrAddress `db:"-"`
+	Cities    []dbrAddress  `db:"-"`
+}
+
+func TestPreloadHasMany(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	byCity := func(b *SelectBuilder) *SelectBuilder { return b.OrderBy("city DESC") }
+
+	var people []*dbrPersonWithAddresses
+	count, err := s.Select("*").From("dbr_people").OrderBy("id").
+		Preload(HasMany("Addresses", "dbr_addresses", "person_id")).
+		Preload(&Relation{Field: "Cities", Table: "dbr_addresses", ForeignKey: "person_id", PrimaryKey: "id", Scopes: []SelectScope{byCity}}).
+		LoadStructs(&people)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	if len(people) == 2 {
+		assert.Equal(t, len(people[0].Addresses), 2)
+		if len(people[0].Addresses) == 2 {
+			assert.Equal(t, people[0].Addresses[0].City, "Boulder")
+			assert.Equal(t, people[0].Addresses[1].City, "San Francisco")
+		}
+		if len(people[0].Cities) == 2 {
+			assert.Equal(t, people[0].Cities[0].City, "San Francisco")
+		}
+		assert.Equal(t, people[1].Addresses, []*dbrAddress{})
+	}
+
+	var person dbrPersonWithAddresses
+	err = s.Select("*").From("dbr_people").Where("name = ?", "Jonathan").
+		Preload(HasMany("Addresses", "dbr_addresses", "person_id")).
+		LoadStruct(&person)
+	assert.NoError(t, err)
+	assert.Equal(t, len(person.Addresses), 2)
+}
+
+func TestPreloadOnlyLoadedRecords(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// A record already in the slice keeps its associations
+	existing := &dbrPersonWithAddresses{Addresses: []*dbrAddress{{City: "Atlantis"}}}
+	existing.Id = 1
+	people := []*dbrPersonWithAddresses{existing}
+	count, err := s.Select("*").From("dbr_people").Where("id = ?", 2).
+		Preload(HasMany("Addresses", "dbr_addresses", "person_id")).
+		LoadStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, count, 1)
+	if assert.Equal(t, len(people), 2) {
+		assert.Equal(t, len(people[0].Addresses), 1)
+		assert.Equal(t, people[0].Addresses[0].City, "Atlantis")
+		assert.Equal(t, people[1].Addresses, []*dbrAddress{})
+	}
+}
+
+func TestPreloadKeyed(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// Map values are copies, so the preloads have to be in place before they're added
+	var byId map[int64]dbrPersonWithAddresses
+	_, err := s.Select("*").From("dbr_people").
+		Preload(HasMany("Addresses", "dbr_addresses", "person_id")).
+		LoadStructsMap(&byId, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, len(byId[1].Addresses), 2)
+	assert.Equal(t, byId[2].Addresses, []*dbrAddress{})
+
+	var byPerson map[int64][]*dbrAddress
+	_, err = s.Select("*").From("dbr_addresses").
+		Preload(BelongsTo("Person", "dbr_people", "person_id")).
+		LoadGrouped(&byPerson, "person_id")
+	assert.NoError(t, err)
+	if assert.Equal(t, len(byPerson[1]), 2) {
+		assert.Equal(t, byPerson[1][0].Person.Name, "Jonathan")
+	}
+}
+
+func TestPreloadBelongsTo(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	tx, err := s.Begin()
+	assert.NoError(t, err)
+	defer tx.RollbackUnlessCommitted()
+
+	var addresses []dbrAddress
+	count, err := tx.Select("*").From("dbr_addresses").OrderBy("id").
+		Preload(BelongsTo("Person", "dbr_people", "person_id")).
+		LoadStructs(&addresses)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 3)
+	if len(addresses) == 3 {
+		if assert.NotEqual(t, addresses[0].Person, (*dbrPerson)(nil)) {
+			assert.Equal(t, addresses[0].Person.Name, "Jonathan")
+		}
+		assert.True(t, addresses[0].Person == addresses[1].Person)
+		assert.Equal(t, addresses[2].Person, (*dbrPerson)(nil))
+	}
+}
+
+type dbrAddressOwner struct {
+	PersonId NullInt64
+}
+
+type dbrAddressWithOwner struct {
+	Id    int64
+	Owner *dbrAddressOwner
+	City  string
+}
+
+type dbrPersonWithOwnedAddresses struct {
+	dbrPerson
+	Addresses []*dbrAddressWithOwner `db:"-"`
+}
+
+func TestPreloadNilNestedKey(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// Addresses loaded without their person_id leave the nested pointer holding it nil, and match nothing
+	withoutKey := func(b *SelectBuilder) *SelectBuilder {
+		b.Columns = []interface{}{"id", "city"}
+		return b
+	}
+
+	var people []*dbrPersonWithOwnedAddresses
+	_, err := s.Select("*").From("dbr_people").OrderBy("id").
+		Preload(&Relation{Field: "Addresses", Table: "dbr_addresses", ForeignKey: "person_id", PrimaryKey: "id", Scopes: []SelectScope{withoutKey}}).
+		LoadStructs(&people)
+	assert.NoError(t, err)
+	if assert.Equal(t, len(people), 2) {
+		assert.Equal(t, len(people[0].Addresses), 0)
+		assert.Equal(t, len(people[1].Addresses), 0)
+	}
+}
+
+func TestPreloadInvalidRelation(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var addresses []*dbrAddress
+	_, err := s.Select("*").From("dbr_addresses").Preload(HasMany("Wat", "dbr_people", "id")).LoadStructs(&addresses)
+	assert.Equal(t, err.Error(), "dbr: select dbr_addresses: preload: dbr.dbrAddress has no field Wat")
+
+	_, err = s.Select("*").From("dbr_addresses").Preload(HasMany("Person", "dbr_people", "id")).LoadStructs(&addresses)
+	assert.Equal(t, err.Error(), "dbr: select dbr_addresses: preload: HasMany field Person must be a slice")
+}
@@ -2,6 +2,7 @@ package dbr
 
 import (
 	"bytes"
+	"errors"
 )
 
 // Quoter is the quoter to use for quoting text; use Mysql quoting by default
@@ -20,3 +21,29 @@ func (q MysqlQuoter) writeQuotedColumn(column string, sql *bytes.Buffer) {
 	sql.WriteString(column)
 	sql.WriteRune('`')
 }
+
+// writeExcludedColumn writes a reference to the value column would have been inserted with, in an upsert
+func (q MysqlQuoter) writeExcludedColumn(column string, sql *bytes.Buffer) {
+	sql.WriteString("VALUES(")
+	q.writeQuotedColumn(column, sql)
+	sql.WriteRune(')')
+}
+
+// checkInsertModifiers returns an error if b combines INSERT modifiers that MySQL doesn't allow together
+func (q MysqlQuoter) checkInsertModifiers(b *InsertBuilder) error {
+	switch {
+	case b.IsHighPriority && b.IsDelayed:
+		return errors.New("insert can't be both HIGH_PRIORITY and DELAYED")
+	case b.IsReplace && b.IsIgnore:
+		return errors.New("replace doesn't support IGNORE")
+	case b.IsReplace && b.IsHighPriority:
+		return errors.New("replace doesn't support HIGH_PRIORITY")
+	case b.IsReplace && b.Upsert != nil:
+		return errors.New("replace doesn't support ON DUPLICATE KEY UPDATE")
+	case b.IsDelayed && b.Upsert != nil:
+		return errors.New("DELAYED doesn't support ON DUPLICATE KEY UPDATE")
+	case b.IsDelayed && b.FromSelect != nil:
+		return errors.New("DELAYED doesn't support inserting from a select")
+	}
+	return nil
+}
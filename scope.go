@@ -0,0 +1,139 @@
+package dbr
+
+import (
+	"strings"
+)
+
+// SelectScope is a reusable modification of a SelectBuilder, such as a common WHERE clause
+type SelectScope func(*SelectBuilder) *SelectBuilder
+
+// UpdateScope is a reusable modification of an UpdateBuilder
+type UpdateScope func(*UpdateBuilder) *UpdateBuilder
+
+// DeleteScope is a reusable modification of a DeleteBuilder
+type DeleteScope func(*DeleteBuilder) *DeleteBuilder
+
+// AddDefaultSelectScopes registers scopes that are applied to every SELECT from table built by the session.
+// Default scopes are applied when the SQL is generated, so they see the builder's final FROM table.
+// The table is matched without any alias, so From("users u") and From("users AS u") get the scopes of "users".
+// Register scopes before using the session from multiple goroutines.
+func (sess *Session) AddDefaultSelectScopes(table string, scopes ...SelectScope) {
+	if sess.selectScopes == nil {
+		sess.selectScopes = make(map[string][]SelectScope)
+	}
+	sess.selectScopes[table] = append(sess.selectScopes[table], scopes...)
+}
+
+// AddDefaultUpdateScopes registers scopes that are applied to every UPDATE of table built by the session
+func (sess *Session) AddDefaultUpdateScopes(table string, scopes ...UpdateScope) {
+	if sess.updateScopes == nil {
+		sess.updateScopes = make(map[string][]UpdateScope)
+	}
+	sess.updateScopes[table] = append(sess.updateScopes[table], scopes...)
+}
+
+// AddDefaultDeleteScopes registers scopes that are applied to every DELETE from table built by the session
+func (sess *Session) AddDefaultDeleteScopes(table string, scopes ...DeleteScope) {
+	if sess.deleteScopes == nil {
+		sess.deleteScopes = make(map[string][]DeleteScope)
+	}
+	sess.deleteScopes[table] = append(sess.deleteScopes[table], scopes...)
+}
+
+// scopeTable returns the table that default scopes are registered for from a table reference like "users AS u"
+func scopeTable(table string) string {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], "`")
+}
+
+// Scopes applies the given scopes to the statement in order
+func (b *SelectBuilder) Scopes(scopes ...SelectScope) *SelectBuilder {
+	for _, scope := range scopes {
+		b = scope(b)
+	}
+	return b
+}
+
+// Unscoped prevents the session's default scopes from being applied to the statement
+func (b *SelectBuilder) Unscoped() *SelectBuilder {
+	b = b.mutable()
+	b.unscoped = true
+	return b
+}
+
+// withDefaultScopes returns a copy of b with the session's default scopes for its table applied,
+// or b itself if there are none to apply
+func (b *SelectBuilder) withDefaultScopes() *SelectBuilder {
+	if b.unscoped {
+		return b
+	}
+	scopes := b.selectScopes[scopeTable(b.FromTable)]
+	if len(scopes) == 0 {
+		return b
+	}
+
+	scoped := b.Clone()
+	scoped.unscoped = true
+	return scoped.Scopes(scopes...)
+}
+
+// Scopes applies the given scopes to the statement in order
+func (b *UpdateBuilder) Scopes(scopes ...UpdateScope) *UpdateBuilder {
+	for _, scope := range scopes {
+		b = scope(b)
+	}
+	return b
+}
+
+// Unscoped prevents the session's default scopes from being applied to the statement
+func (b *UpdateBuilder) Unscoped() *UpdateBuilder {
+	b = b.mutable()
+	b.unscoped = true
+	return b
+}
+
+func (b *UpdateBuilder) withDefaultScopes() *UpdateBuilder {
+	if b.unscoped {
+		return b
+	}
+	scopes := b.updateScopes[scopeTable(b.Table)]
+	if len(scopes) == 0 {
+		return b
+	}
+
+	scoped := b.Clone()
+	scoped.unscoped = true
+	return scoped.Scopes(scopes...)
+}
+
+// Scopes applies the given scopes to the statement in order
+func (b *DeleteBuilder) Scopes(scopes ...DeleteScope) *DeleteBuilder {
+	for _, scope := range scopes {
+		b = scope(b)
+	}
+	return b
+}
+
+// Unscoped prevents the session's default scopes from being applied to the statement
+func (b *DeleteBuilder) Unscoped() *DeleteBuilder {
+	b = b.mutable()
+	b.unscoped = true
+	return b
+}
+
+func (b *DeleteBuilder) withDefaultScopes() *DeleteBuilder {
+	if b.unscoped {
+		return b
+	}
+	scopes := b.deleteScopes[scopeTable(b.From)]
+	if len(scopes) == 0 {
+		return b
+	}
+
+	scoped := b.Clone()
+	scoped.unscoped = true
+	return scoped.Scopes(scopes...)
+}
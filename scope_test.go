@@ -0,0 +1,101 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func notDeleted(b *SelectBuilder) *SelectBuilder {
+	return b.Where("deleted_at IS NULL")
+}
+
+func visibleTo(accountId int64) SelectScope {
+	return func(b *SelectBuilder) *SelectBuilder {
+		return b.Where("account_id = ?", accountId)
+	}
+}
+
+func TestSelectScopes(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.Select("a").From("b").Where("c = ?", 1).Scopes(notDeleted, visibleTo(9)).ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (c = ?) AND (deleted_at IS NULL) AND (account_id = ?)")
+	assert.Equal(t, args, []interface{}{1, int64(9)})
+}
+
+func TestSelectDefaultScopes(t *testing.T) {
+	s := createFakeSession()
+	s.AddDefaultSelectScopes("b", notDeleted)
+
+	builder := s.Select("a").From("b").Where("c = ?", 1)
+	sql, args, err := builder.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (c = ?) AND (deleted_at IS NULL)")
+	assert.Equal(t, args, []interface{}{1})
+
+	// Generating SQL doesn't modify the builder
+	assert.Equal(t, len(builder.WhereFragments), 1)
+
+	sql, _, err = s.Select("a").From("b").Unscoped().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b")
+
+	sql, _, err = s.Select("a").From("other").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM other")
+
+	// The table is matched without its alias
+	for _, from := range []string{"b x", "b AS x", "`b` x"} {
+		sql, _, err = s.Select("a").From(from).ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, sql, "SELECT a FROM "+from+" WHERE (deleted_at IS NULL)")
+	}
+	sql, _, err = s.Select("a").From("bb b").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM bb b")
+}
+
+func TestUpdateDefaultScopes(t *testing.T) {
+	s := createFakeSession()
+	s.AddDefaultUpdateScopes("a", func(b *UpdateBuilder) *UpdateBuilder {
+		return b.Set("updated_at", Expr("NOW()"))
+	})
+
+	sql, args, err := s.Update("a").Set("b", 1).Where("c = ?", 2).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "UPDATE a SET `b` = ?, `updated_at` = NOW() WHERE (c = ?)")
+	assert.Equal(t, args, []interface{}{1, 2})
+
+	sql, _, err = s.Update("a AS x").Set("b", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "UPDATE a AS x SET `b` = ?, `updated_at` = NOW()")
+
+	sql, args, err = s.Update("a").Set("b", 1).Unscoped().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "UPDATE a SET `b` = ?")
+	assert.Equal(t, args, []interface{}{1})
+}
+
+func TestDeleteDefaultScopes(t *testing.T) {
+	s := createFakeSession()
+	s.AddDefaultDeleteScopes("a", func(b *DeleteBuilder) *DeleteBuilder {
+		return b.Where("account_id = ?", 3)
+	})
+
+	sql, args, err := s.DeleteFrom("a").Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "DELETE FROM a WHERE (id = ?) AND (account_id = ?)")
+	assert.Equal(t, args, []interface{}{1, 3})
+
+	sql, _, err = s.DeleteFrom("a x").Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "DELETE FROM a x WHERE (id = ?) AND (account_id = ?)")
+
+	sql, args, err = s.DeleteFrom("a").Unscoped().Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "DELETE FROM a WHERE (id = ?)")
+	assert.Equal(t, args, []interface{}{1})
+}
@@ -2,6 +2,7 @@ package dbr
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 )
 
@@ -13,9 +14,13 @@ type SelectBuilder struct {
 	RawFullSql   string
 	RawArguments []interface{}
 
+	OptimizerHints  []string
 	IsDistinct      bool
-	Columns         []string
+	IsStraightJoin  bool
+	IsNoCache       bool
+	Columns         []interface{}
 	FromTable       string
+	IndexHints      []string
 	WhereFragments  []*whereFragment
 	GroupBys        []string
 	HavingFragments []*whereFragment
@@ -24,10 +29,18 @@ type SelectBuilder struct {
 	LimitValid      bool
 	OffsetCount     uint64
 	OffsetValid     bool
+	IsStrict        bool
+	Preloads        []*Relation
+
+	immutable bool
+	unscoped  bool
+	err       error
 }
 
-// Select creates a new SelectBuilder that select that given columns
-func (sess *Session) Select(cols ...string) *SelectBuilder {
+// Select creates a new SelectBuilder that select that given columns.
+// Each column is either a string or an Expr, whose arguments precede the WHERE arguments.
+// Since columns are interface{} values, a []string has to be copied into a []interface{} to be passed as cols...
+func (sess *Session) Select(cols ...interface{}) *SelectBuilder {
 	return &SelectBuilder{
 		Session: sess,
 		runner:  sess.cxn.Db,
@@ -46,7 +59,7 @@ func (sess *Session) SelectBySql(sql string, args ...interface{}) *SelectBuilder
 }
 
 // Select creates a new SelectBuilder that select that given columns bound to the transaction
-func (tx *Tx) Select(cols ...string) *SelectBuilder {
+func (tx *Tx) Select(cols ...interface{}) *SelectBuilder {
 	return &SelectBuilder{
 		Session: tx.Session,
 		runner:  tx.Tx,
@@ -64,14 +77,103 @@ func (tx *Tx) SelectBySql(sql string, args ...interface{}) *SelectBuilder {
 	}
 }
 
+// Clone returns a copy of the builder that can be extended without affecting the original
+func (b *SelectBuilder) Clone() *SelectBuilder {
+	c := *b
+	c.RawArguments = cloneArgs(b.RawArguments)
+	c.OptimizerHints = cloneStrings(b.OptimizerHints)
+	c.Columns = cloneArgs(b.Columns)
+	c.IndexHints = cloneStrings(b.IndexHints)
+	c.WhereFragments = cloneWhereFragments(b.WhereFragments)
+	c.GroupBys = cloneStrings(b.GroupBys)
+	c.HavingFragments = cloneWhereFragments(b.HavingFragments)
+	c.OrderBys = cloneStrings(b.OrderBys)
+	if b.Preloads != nil {
+		c.Preloads = append([]*Relation(nil), b.Preloads...)
+	}
+	return &c
+}
+
+// Immutable returns a copy of the builder on which every chained call returns a modified copy
+// instead of changing the builder itself. This makes it safe to keep a base query in a package
+// variable and extend it from many goroutines.
+func (b *SelectBuilder) Immutable() *SelectBuilder {
+	c := b.Clone()
+	c.immutable = true
+	return c
+}
+
+func (b *SelectBuilder) mutable() *SelectBuilder {
+	if b.immutable {
+		return b.Clone()
+	}
+	return b
+}
+
 // Distinct marks the statement as a DISTINCT SELECT
 func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b = b.mutable()
 	b.IsDistinct = true
 	return b
 }
 
-// From sets the table to SELECT FROM
+// StraightJoin marks the statement as SELECT STRAIGHT_JOIN, forcing the tables to be joined in the order listed
+func (b *SelectBuilder) StraightJoin() *SelectBuilder {
+	b = b.mutable()
+	b.IsStraightJoin = true
+	return b
+}
+
+// NoCache marks the statement as SELECT SQL_NO_CACHE
+func (b *SelectBuilder) NoCache() *SelectBuilder {
+	b = b.mutable()
+	b.IsNoCache = true
+	return b
+}
+
+// OptimizerHint appends an optimizer hint such as "MAX_EXECUTION_TIME(1000)" to the statement
+func (b *SelectBuilder) OptimizerHint(hint string) *SelectBuilder {
+	b = b.mutable()
+	b.OptimizerHints = append(b.OptimizerHints, hint)
+	return b
+}
+
+// UseIndex appends a USE INDEX hint for the FROM table
+// It's written after the whole From string, so when From joins tables, write the hints of each table in From instead
+func (b *SelectBuilder) UseIndex(indexes ...string) *SelectBuilder {
+	b = b.mutable()
+	b.IndexHints = append(b.IndexHints, indexHint("USE", indexes))
+	return b
+}
+
+// ForceIndex appends a FORCE INDEX hint for the FROM table
+// Like UseIndex, it can't follow a From that joins tables, which are hinted in From instead
+func (b *SelectBuilder) ForceIndex(indexes ...string) *SelectBuilder {
+	b = b.mutable()
+	b.IndexHints = append(b.IndexHints, indexHint("FORCE", indexes))
+	return b
+}
+
+// IgnoreIndex appends an IGNORE INDEX hint for the FROM table
+// Like UseIndex, it can't follow a From that joins tables, which are hinted in From instead
+func (b *SelectBuilder) IgnoreIndex(indexes ...string) *SelectBuilder {
+	b = b.mutable()
+	b.IndexHints = append(b.IndexHints, indexHint("IGNORE", indexes))
+	return b
+}
+
+// Strict turns on strict mapping for the statement, as if Session.StrictMapping were set
+func (b *SelectBuilder) Strict() *SelectBuilder {
+	b = b.mutable()
+	b.IsStrict = true
+	return b
+}
+
+// From sets the table to SELECT FROM. Joins, with index hints for the joined tables, are written here too, eg
+//
+//	From("a USE INDEX (idx_a) JOIN b FORCE INDEX (idx_b) ON b.a_id = a.id")
 func (b *SelectBuilder) From(from string) *SelectBuilder {
+	b = b.mutable()
 	b.FromTable = from
 	return b
 }
@@ -79,30 +181,45 @@ func (b *SelectBuilder) From(from string) *SelectBuilder {
 // Where appends a WHERE clause to the statement for the given string and args
 // or map of column/value pairs
 func (b *SelectBuilder) Where(whereSqlOrMap interface{}, args ...interface{}) *SelectBuilder {
-	b.WhereFragments = append(b.WhereFragments, newWhereFragment(whereSqlOrMap, args))
+	b = b.mutable()
+	fragment, err := newWhereFragment(whereSqlOrMap, args)
+	if err != nil {
+		b.recordError(err)
+		return b
+	}
+	b.WhereFragments = append(b.WhereFragments, fragment)
 	return b
 }
 
 // GroupBy appends a column to group the statement
 func (b *SelectBuilder) GroupBy(group string) *SelectBuilder {
+	b = b.mutable()
 	b.GroupBys = append(b.GroupBys, group)
 	return b
 }
 
 // Having appends a HAVING clause to the statement
 func (b *SelectBuilder) Having(whereSqlOrMap interface{}, args ...interface{}) *SelectBuilder {
-	b.HavingFragments = append(b.HavingFragments, newWhereFragment(whereSqlOrMap, args))
+	b = b.mutable()
+	fragment, err := newWhereFragment(whereSqlOrMap, args)
+	if err != nil {
+		b.recordError(err)
+		return b
+	}
+	b.HavingFragments = append(b.HavingFragments, fragment)
 	return b
 }
 
 // OrderBy appends a column to ORDER the statement by
 func (b *SelectBuilder) OrderBy(ord string) *SelectBuilder {
+	b = b.mutable()
 	b.OrderBys = append(b.OrderBys, ord)
 	return b
 }
 
 // OrderDir appends a column to ORDER the statement by with a given direction
 func (b *SelectBuilder) OrderDir(ord string, isAsc bool) *SelectBuilder {
+	b = b.mutable()
 	if isAsc {
 		b.OrderBys = append(b.OrderBys, ord+" ASC")
 	} else {
@@ -113,6 +230,7 @@ func (b *SelectBuilder) OrderDir(ord string, isAsc bool) *SelectBuilder {
 
 // Limit sets a limit for the statement; overrides any existing LIMIT
 func (b *SelectBuilder) Limit(limit uint64) *SelectBuilder {
+	b = b.mutable()
 	b.LimitCount = limit
 	b.LimitValid = true
 	return b
@@ -120,6 +238,7 @@ func (b *SelectBuilder) Limit(limit uint64) *SelectBuilder {
 
 // Offset sets an offset for the statement; overrides any existing OFFSET
 func (b *SelectBuilder) Offset(offset uint64) *SelectBuilder {
+	b = b.mutable()
 	b.OffsetCount = offset
 	b.OffsetValid = true
 	return b
@@ -128,47 +247,94 @@ func (b *SelectBuilder) Offset(offset uint64) *SelectBuilder {
 // Paginate sets LIMIT/OFFSET for the statement based on the given page/perPage
 // Assumes page/perPage are valid. Page and perPage must be >= 1
 func (b *SelectBuilder) Paginate(page, perPage uint64) *SelectBuilder {
-	b.Limit(perPage)
-	b.Offset((page - 1) * perPage)
-	return b
+	return b.Limit(perPage).Offset((page - 1) * perPage)
+}
+
+// As returns the statement as a parenthesized subquery Expr with the given alias,
+// for use as a column of another SelectBuilder
+func (b *SelectBuilder) As(alias string) *expr {
+	sql, args, err := b.ToSql()
+	e := Expr("("+sql+") AS "+alias, args...)
+	e.err = err
+	return e
+}
+
+// recordError records err, the first error building the statement, to be returned by ToSql and the Load* methods
+func (b *SelectBuilder) recordError(err error) {
+	err = b.buildError(err)
+	if b.err == nil {
+		b.err = err
+	}
 }
 
 // ToSql serialized the SelectBuilder to a SQL string
-// It returns the string with placeholders and a slice of query arguments
-func (b *SelectBuilder) ToSql() (string, []interface{}) {
+// It returns the string with placeholders and a slice of query arguments,
+// or an error if the statement is invalid
+func (b *SelectBuilder) ToSql() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
 	if b.RawFullSql != "" {
-		return b.RawFullSql, b.RawArguments
+		return b.RawFullSql, b.RawArguments, nil
+	}
+
+	if scoped := b.withDefaultScopes(); scoped != b {
+		return scoped.ToSql()
 	}
 
 	if len(b.Columns) == 0 {
-		panic("no columns specified")
+		return "", nil, b.buildError(errors.New("no columns specified"))
 	}
 	if len(b.FromTable) == 0 {
-		panic("no table specified")
+		return "", nil, b.buildError(errors.New("no table specified"))
 	}
 
 	var sql bytes.Buffer
 	var args []interface{}
 
 	sql.WriteString("SELECT ")
+	writeOptimizerHintsToSql(b.OptimizerHints, &sql)
 
 	if b.IsDistinct {
 		sql.WriteString("DISTINCT ")
 	}
 
-	for i, s := range b.Columns {
+	if b.IsStraightJoin {
+		sql.WriteString("STRAIGHT_JOIN ")
+	}
+
+	if b.IsNoCache {
+		sql.WriteString("SQL_NO_CACHE ")
+	}
+
+	for i, c := range b.Columns {
 		if i > 0 {
 			sql.WriteString(", ")
 		}
-		sql.WriteString(s)
+		switch col := c.(type) {
+		case string:
+			sql.WriteString(col)
+		case *expr:
+			if col.err != nil {
+				return "", nil, b.buildError(col.err)
+			}
+			sql.WriteString(col.Sql)
+			args = append(args, col.Values...)
+		default:
+			return "", nil, b.buildError(errors.New("invalid column type. Pass a string or an Expr"))
+		}
 	}
 
 	sql.WriteString(" FROM ")
 	sql.WriteString(b.FromTable)
+	writeIndexHintsToSql(b.IndexHints, &sql)
 
 	if len(b.WhereFragments) > 0 {
 		sql.WriteString(" WHERE ")
-		writeWhereFragmentsToSql(b.WhereFragments, &sql, &args)
+		if err := writeWhereFragmentsToSql(b.WhereFragments, &sql, &args); err != nil {
+			return "", nil, b.buildError(err)
+		}
 	}
 
 	if len(b.GroupBys) > 0 {
@@ -183,7 +349,9 @@ func (b *SelectBuilder) ToSql() (string, []interface{}) {
 
 	if len(b.HavingFragments) > 0 {
 		sql.WriteString(" HAVING ")
-		writeWhereFragmentsToSql(b.HavingFragments, &sql, &args)
+		if err := writeWhereFragmentsToSql(b.HavingFragments, &sql, &args); err != nil {
+			return "", nil, b.buildError(err)
+		}
 	}
 
 	if len(b.OrderBys) > 0 {
@@ -206,5 +374,5 @@ func (b *SelectBuilder) ToSql() (string, []interface{}) {
 		fmt.Fprint(&sql, b.OffsetCount)
 	}
 
-	return sql.String(), args
+	return sql.String(), args, nil
 }
@@ -1,19 +1,24 @@
 package dbr
 
 import (
+	"database/sql"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// Unvetted thots:
 // Given a query and given a structure (field list), there's 2 sets of fields.
 // Take the intersection. We can fill those in. great.
-// For fields in the structure that aren't in the query, we'll let that slide if db:"-"
-// For fields in the structure that aren't in the query but without db:"-", return error
-// For fields in the query that aren't in the structure, we'll ignore them.
+// By default, everything outside the intersection is ignored. In strict mapping mode
+// (Session.StrictMapping or SelectBuilder.Strict), we instead return a *MappingError if:
+//  - a field in the structure isn't in the query, unless it's tagged db:"-"
+//  - a column in the query isn't in the structure
 
 // LoadStructs executes the SelectBuilder and loads the resulting data into a slice of structs
-// dest must be a pointer to a slice of pointers to structs
+// dest must be a pointer to a slice of structs or a slice of pointers to structs
+// Records that implement AfterLoader have AfterLoad called once they're loaded
 // Returns the number of items found (which is not necessarily the # of items set)
 func (b *SelectBuilder) LoadStructs(dest interface{}) (int, error) {
 	//
@@ -36,82 +41,51 @@ func (b *SelectBuilder) LoadStructs(dest interface{}) (int, error) {
 		panic("invalid type passed to LoadStructs. Need a pointer to a slice")
 	}
 
-	// The slice elements must be pointers to structures
+	// The slice elements must be structures or pointers to structures
 	recordType := valueOfDest.Type().Elem()
-	if recordType.Kind() != reflect.Ptr {
-		panic("Elements need to be pointers to structures")
+	recordTypeIsPtr := recordType.Kind() == reflect.Ptr
+	if recordTypeIsPtr {
+		recordType = recordType.Elem()
 	}
 
-	recordType = recordType.Elem()
 	if recordType.Kind() != reflect.Struct {
-		panic("Elements need to be pointers to structures")
+		panic("Elements need to be structures or pointers to structures")
 	}
 
-	//
-	// Get full SQL
-	//
-	fullSql, err := Interpolate(b.ToSql())
+	r, err := b.queryRecords("dbr.select.load_all", recordType)
 	if err != nil {
-		return 0, b.EventErr("dbr.select.load_all.interpolate", err)
-	}
-
-	numberOfRowsReturned := 0
-
-	// Start the timer:
-	startTime := time.Now()
-	defer func() { b.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), kvs{"sql": fullSql}) }()
-
-	// Run the query:
-	rows, err := b.runner.Query(fullSql)
-	if err != nil {
-		return 0, b.EventErrKv("dbr.select.load_all.query", err, kvs{"sql": fullSql})
-	}
-	defer rows.Close()
-
-	// Get the columns returned
-	columns, err := rows.Columns()
-	if err != nil {
-		return numberOfRowsReturned, b.EventErrKv("dbr.select.load_one.rows.Columns", err, kvs{"sql": fullSql})
-	}
-
-	// Create a map of this result set to the struct fields
-	fieldMap, err := b.calculateFieldMap(recordType, columns, false)
-	if err != nil {
-		return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all.calculateFieldMap", err, kvs{"sql": fullSql})
+		return 0, err
 	}
-
-	// Build a 'holder', which is an []interface{}. Each value will be the set to address of the field corresponding to our newly made records:
-	holder := make([]interface{}, len(fieldMap))
+	defer r.close()
 
 	// Iterate over rows and scan their data into the structs
+	numberOfRowsReturned := 0
 	sliceValue := valueOfDest
-	for rows.Next() {
+	for r.next() {
 		// Create a new record to store our row:
 		pointerToNewRecord := reflect.New(recordType)
 		newRecord := reflect.Indirect(pointerToNewRecord)
 
-		// Prepare the holder for this record
-		scannable, err := b.prepareHolderFor(newRecord, fieldMap, holder)
-		if err != nil {
-			return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all.holderFor", err, kvs{"sql": fullSql})
-		}
-
 		// Load up our new structure with the row's values
-		err = rows.Scan(scannable...)
-		if err != nil {
-			return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all.scan", err, kvs{"sql": fullSql})
+		if err := r.scan(newRecord); err != nil {
+			return numberOfRowsReturned, err
 		}
 
 		// Append our new record to the slice:
-		sliceValue = reflect.Append(sliceValue, pointerToNewRecord)
+		if recordTypeIsPtr {
+			sliceValue = reflect.Append(sliceValue, pointerToNewRecord)
+		} else {
+			sliceValue = reflect.Append(sliceValue, newRecord)
+		}
 
 		numberOfRowsReturned++
 	}
 	valueOfDest.Set(sliceValue)
 
-	// Check for errors at the end. Supposedly these are error that can happen during iteration.
-	if err = rows.Err(); err != nil {
-		return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all.rows_err", err, kvs{"sql": fullSql})
+	// Preload and run the AfterLoad hooks of just the records we loaded, not any that were already in dest
+	newRecords := valueOfDest.Slice(valueOfDest.Len()-numberOfRowsReturned, valueOfDest.Len())
+	if err := r.finish(newRecords); err != nil {
+		return numberOfRowsReturned, err
 	}
 
 	return numberOfRowsReturned, nil
@@ -119,6 +93,7 @@ func (b *SelectBuilder) LoadStructs(dest interface{}) (int, error) {
 
 // LoadStruct executes the SelectBuilder and loads the resulting data into a struct
 // dest must be a pointer to a struct
+// If dest implements AfterLoader, AfterLoad is called once it's loaded
 // Returns ErrNotFound if nothing was found
 func (b *SelectBuilder) LoadStruct(dest interface{}) error {
 	//
@@ -134,96 +109,198 @@ func (b *SelectBuilder) LoadStruct(dest interface{}) error {
 
 	recordType := indirectOfDest.Type()
 
+	r, err := b.queryRecords("dbr.select.load_one", recordType)
+	if err != nil {
+		return err
+	}
+	defer r.close()
+
+	// Load the first row straight into dest
+	records := reflect.MakeSlice(reflect.SliceOf(valueOfDest.Type()), 0, 1)
+	if r.next() {
+		if err := r.scan(indirectOfDest); err != nil {
+			return err
+		}
+		records = reflect.Append(records, valueOfDest)
+	}
+	if err := r.finish(records); err != nil {
+		return err
+	}
+
+	if records.Len() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// recordRows is a result set being loaded into structs, by LoadStructs, LoadStruct, LoadStructsMap and LoadGrouped.
+// Its errors are reported as events named after the loader, eg dbr.select.load_all.scan.
+type recordRows struct {
+	b         *SelectBuilder
+	eventName string
+	sql       string
+	fullSql   string
+	startTime time.Time
+
+	rows     *sql.Rows
+	columns  []string
+	fieldMap [][]int
+	holder   []interface{}
+	deferred *deferredColumns
+}
+
+// queryRecords runs the statement and maps the columns of its result set to the fields of recordType.
+// The returned recordRows must be closed.
+func (b *SelectBuilder) queryRecords(eventName string, recordType reflect.Type) (*recordRows, error) {
 	//
 	// Get full SQL
 	//
-	fullSql, err := Interpolate(b.ToSql())
+	sql, args, err := b.ToSql()
 	if err != nil {
-		return err
+		return nil, b.errorKv(eventName+".to_sql", sql, err, nil)
+	}
+	fullSql, err := Interpolate(sql, args)
+	if err != nil {
+		return nil, b.errorKv(eventName+".interpolate", sql, err, nil)
 	}
 
-	// Start the timer:
-	startTime := time.Now()
-	defer func() { b.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), kvs{"sql": fullSql}) }()
+	// Start the timer, which close stops
+	r := &recordRows{b: b, eventName: eventName, sql: sql, fullSql: fullSql, startTime: time.Now()}
 
 	// Run the query:
-	rows, err := b.runner.Query(fullSql)
+	r.rows, err = b.runner.Query(fullSql)
 	if err != nil {
-		return b.EventErrKv("dbr.select.load_one.query", err, kvs{"sql": fullSql})
+		r.close()
+		return nil, r.errorKv("query", err, nil)
 	}
-	defer rows.Close()
 
-	// Get the columns of this result set
-	columns, err := rows.Columns()
+	// Get the columns returned
+	r.columns, err = r.rows.Columns()
 	if err != nil {
-		return b.EventErrKv("dbr.select.load_one.rows.Columns", err, kvs{"sql": fullSql})
+		r.close()
+		return nil, r.errorKv("rows.Columns", err, nil)
 	}
 
-	// Create a map of this result set to the struct columns
-	fieldMap, err := b.calculateFieldMap(recordType, columns, false)
+	// Create a map of this result set to the struct fields
+	mapping, err := b.calculateFieldMapping(recordType, r.columns, false)
 	if err != nil {
-		return b.EventErrKv("dbr.select.load_one.calculateFieldMap", err, kvs{"sql": fullSql})
+		r.close()
+		return nil, r.errorKv("calculateFieldMap", err, nil)
+	}
+	r.fieldMap = mapping.fieldMap
+	if b.IsStrict || b.StrictMapping {
+		if err := checkStrictMapping(recordType, r.columns, r.fieldMap); err != nil {
+			r.close()
+			return nil, r.errorKv("strict_mapping", err, nil)
+		}
 	}
 
-	// Build a 'holder', which is an []interface{}. Each value will be the set to address of the field corresponding to our newly made records:
-	holder := make([]interface{}, len(fieldMap))
+	// Build a 'holder', which is an []interface{}. Each value will be the set to address of the field corresponding to the records:
+	r.holder = make([]interface{}, len(r.fieldMap))
+	r.deferred = newDeferredColumns(recordType, mapping)
 
-	if rows.Next() {
-		// Build a 'holder', which is an []interface{}. Each value will be the address of the field corresponding to our newly made record:
-		scannable, err := b.prepareHolderFor(indirectOfDest, fieldMap, holder)
-		if err != nil {
-			return b.EventErrKv("dbr.select.load_one.holderFor", err, kvs{"sql": fullSql})
-		}
+	return r, nil
+}
 
-		// Load up our new structure with the row's values
-		err = rows.Scan(scannable...)
-		if err != nil {
-			return b.EventErrKv("dbr.select.load_one.scan", err, kvs{"sql": fullSql})
-		}
-		return nil
+// next advances to the next row, like sql.Rows.Next
+func (r *recordRows) next() bool {
+	return r.rows.Next()
+}
+
+// scan loads the current row into record, a struct
+func (r *recordRows) scan(record reflect.Value) error {
+	scannable, err := r.b.prepareHolderFor(record, r.fieldMap, r.holder, r.deferred)
+	if err != nil {
+		return r.errorKv("holderFor", err, nil)
+	}
+	if err := r.rows.Scan(scannable...); err != nil {
+		return r.errorKv("scan", err, nil)
 	}
+	if err := r.b.scanDeferredColumns(r.rows, record, r.fieldMap, r.holder, r.deferred); err != nil {
+		return r.errorKv("scan_deferred", err, nil)
+	}
+	return nil
+}
 
-	if err := rows.Err(); err != nil {
-		return b.EventErrKv("dbr.select.load_one.rows_err", err, kvs{"sql": fullSql})
+// finish checks for an error that ended the rows, and then runs the preloads and AfterLoad hooks of records,
+// a slice of the loaded structs or pointers to them
+func (r *recordRows) finish(records reflect.Value) error {
+	// Check for errors at the end. Supposedly these are error that can happen during iteration.
+	if err := r.rows.Err(); err != nil {
+		return r.errorKv("rows_err", err, nil)
 	}
 
-	return ErrNotFound
+	if len(r.b.Preloads) > 0 {
+		// Free the connection for the preload queries
+		r.rows.Close()
+		if err := r.b.preload(records); err != nil {
+			return err
+		}
+	}
+
+	if err := afterLoad(records); err != nil {
+		return r.errorKv("after_load", err, nil)
+	}
+	return nil
 }
 
-// LoadValues executes the SelectBuilder and loads the resulting data into a slice of primitive values
-// Returns ErrNotFound if no value was found, and it was therefore not set.
-func (b *SelectBuilder) LoadValues(dest interface{}) (int, error) {
-	// Validate the dest and reflection values we need
+// close closes the rows and reports the time the load took
+func (r *recordRows) close() {
+	if r.rows != nil {
+		r.rows.Close()
+	}
+	r.b.TimingKv("dbr.select", time.Since(r.startTime).Nanoseconds(), kvs{"sql": r.fullSql})
+}
 
-	// This must be a pointer to a slice
-	valueOfDest := reflect.ValueOf(dest)
-	kindOfDest := valueOfDest.Kind()
+// errorKv reports err in the given stage of loading the rows, with the statement and any other kvs
+func (r *recordRows) errorKv(stage string, err error, other kvs) error {
+	kv := kvs{"sql": r.fullSql}
+	for k, v := range other {
+		kv[k] = v
+	}
+	return r.b.errorKv(r.eventName+"."+stage, r.sql, err, kv)
+}
 
-	if kindOfDest != reflect.Ptr {
-		panic("invalid type passed to LoadValues. Need a pointer to a slice")
+// LoadValues executes the SelectBuilder and loads the resulting data into slices of primitive values,
+// one slice per selected column, eg LoadValues(&ids, &names) for SELECT id, name
+// Returns ErrColumnMismatch if the number of dests doesn't match the number of columns
+func (b *SelectBuilder) LoadValues(dests ...interface{}) (int, error) {
+	// Validate the dests and reflection values we need
+	if len(dests) == 0 {
+		panic("LoadValues needs at least one destination")
 	}
 
-	// This must a slice
-	valueOfDest = reflect.Indirect(valueOfDest)
-	kindOfDest = valueOfDest.Kind()
+	sliceValues := make([]reflect.Value, len(dests))
+	for i, dest := range dests {
+		// This must be a pointer to a slice
+		valueOfDest := reflect.ValueOf(dest)
+		kindOfDest := valueOfDest.Kind()
 
-	if kindOfDest != reflect.Slice {
-		panic("invalid type passed to LoadValues. Need a pointer to a slice")
-	}
+		if kindOfDest != reflect.Ptr {
+			panic("invalid type passed to LoadValues. Need a pointer to a slice")
+		}
 
-	recordType := valueOfDest.Type().Elem()
+		// This must a slice
+		valueOfDest = reflect.Indirect(valueOfDest)
+		kindOfDest = valueOfDest.Kind()
 
-	recordTypeIsPtr := recordType.Kind() == reflect.Ptr
-	if recordTypeIsPtr {
-		reflect.ValueOf(dest)
+		if kindOfDest != reflect.Slice {
+			panic("invalid type passed to LoadValues. Need a pointer to a slice")
+		}
+
+		sliceValues[i] = valueOfDest
 	}
 
 	//
 	// Get full SQL
 	//
-	fullSql, err := Interpolate(b.ToSql())
+	sql, args, err := b.ToSql()
 	if err != nil {
-		return 0, err
+		return 0, b.errorKv("dbr.select.load_all_values.to_sql", sql, err, nil)
+	}
+	fullSql, err := Interpolate(sql, args)
+	if err != nil {
+		return 0, b.errorKv("dbr.select.load_all_values.interpolate", sql, err, nil)
 	}
 
 	numberOfRowsReturned := 0
@@ -235,52 +312,73 @@ func (b *SelectBuilder) LoadValues(dest interface{}) (int, error) {
 	// Run the query:
 	rows, err := b.runner.Query(fullSql)
 	if err != nil {
-		return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all_values.query", err, kvs{"sql": fullSql})
+		return numberOfRowsReturned, b.errorKv("dbr.select.load_all_values.query", sql, err, kvs{"sql": fullSql})
 	}
 	defer rows.Close()
 
-	sliceValue := valueOfDest
+	if err := checkColumnCount(rows, len(dests)); err != nil {
+		return numberOfRowsReturned, b.errorKv("dbr.select.load_all_values.columns", sql, err, kvs{"sql": fullSql})
+	}
+
+	pointersToNewValues := make([]reflect.Value, len(dests))
+	scannable := make([]interface{}, len(dests))
 	for rows.Next() {
-		// Create a new value to store our row:
-		pointerToNewValue := reflect.New(recordType)
-		newValue := reflect.Indirect(pointerToNewValue)
+		// Create new values to store our row.
+		// For pointer element types like []*string, we scan into a **string so NULLs become nil elements
+		for i, sliceValue := range sliceValues {
+			pointersToNewValues[i] = reflect.New(sliceValue.Type().Elem())
+			scannable[i] = pointersToNewValues[i].Interface()
+		}
 
-		err = rows.Scan(pointerToNewValue.Interface())
+		err = rows.Scan(scannable...)
 		if err != nil {
-			return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all_values.scan", err, kvs{"sql": fullSql})
+			return numberOfRowsReturned, b.errorKv("dbr.select.load_all_values.scan", sql, err, kvs{"sql": fullSql})
 		}
 
-		// Append our new value to the slice:
-		sliceValue = reflect.Append(sliceValue, newValue)
+		// Append our new values to the slices:
+		for i, pointerToNewValue := range pointersToNewValues {
+			sliceValues[i] = reflect.Append(sliceValues[i], reflect.Indirect(pointerToNewValue))
+		}
 
 		numberOfRowsReturned++
 	}
-	valueOfDest.Set(sliceValue)
+	for i, dest := range dests {
+		reflect.Indirect(reflect.ValueOf(dest)).Set(sliceValues[i])
+	}
 
 	if err := rows.Err(); err != nil {
-		return numberOfRowsReturned, b.EventErrKv("dbr.select.load_all_values.rows_err", err, kvs{"sql": fullSql})
+		return numberOfRowsReturned, b.errorKv("dbr.select.load_all_values.rows_err", sql, err, kvs{"sql": fullSql})
 	}
 
 	return numberOfRowsReturned, nil
 }
 
-// LoadValue executes the SelectBuilder and loads the resulting data into a primitive value
+// LoadValue executes the SelectBuilder and loads the first row into primitive values,
+// one per selected column, eg LoadValue(&min, &max) for SELECT MIN(x), MAX(x)
 // Returns ErrNotFound if no value was found, and it was therefore not set.
-func (b *SelectBuilder) LoadValue(dest interface{}) error {
-	// Validate the dest
-	valueOfDest := reflect.ValueOf(dest)
-	kindOfDest := valueOfDest.Kind()
+// Returns ErrColumnMismatch if the number of dests doesn't match the number of columns
+func (b *SelectBuilder) LoadValue(dests ...interface{}) error {
+	// Validate the dests
+	if len(dests) == 0 {
+		panic("LoadValue needs at least one destination")
+	}
 
-	if kindOfDest != reflect.Ptr {
-		panic("Destination must be a pointer")
+	for _, dest := range dests {
+		if reflect.ValueOf(dest).Kind() != reflect.Ptr {
+			panic("Destination must be a pointer")
+		}
 	}
 
 	//
 	// Get full SQL
 	//
-	fullSql, err := Interpolate(b.ToSql())
+	sql, args, err := b.ToSql()
 	if err != nil {
-		return err
+		return b.errorKv("dbr.select.load_value.to_sql", sql, err, nil)
+	}
+	fullSql, err := Interpolate(sql, args)
+	if err != nil {
+		return b.errorKv("dbr.select.load_value.interpolate", sql, err, nil)
 	}
 
 	// Start the timer:
@@ -290,21 +388,202 @@ func (b *SelectBuilder) LoadValue(dest interface{}) error {
 	// Run the query:
 	rows, err := b.runner.Query(fullSql)
 	if err != nil {
-		return b.EventErrKv("dbr.select.load_value.query", err, kvs{"sql": fullSql})
+		return b.errorKv("dbr.select.load_value.query", sql, err, kvs{"sql": fullSql})
 	}
 	defer rows.Close()
 
+	if err := checkColumnCount(rows, len(dests)); err != nil {
+		return b.errorKv("dbr.select.load_value.columns", sql, err, kvs{"sql": fullSql})
+	}
+
 	if rows.Next() {
-		err = rows.Scan(dest)
+		err = rows.Scan(dests...)
 		if err != nil {
-			return b.EventErrKv("dbr.select.load_value.scan", err, kvs{"sql": fullSql})
+			return b.errorKv("dbr.select.load_value.scan", sql, err, kvs{"sql": fullSql})
 		}
 		return nil
 	}
 
 	if err := rows.Err(); err != nil {
-		return b.EventErrKv("dbr.select.load_value.rows_err", err, kvs{"sql": fullSql})
+		return b.errorKv("dbr.select.load_value.rows_err", sql, err, kvs{"sql": fullSql})
 	}
 
 	return ErrNotFound
 }
+
+// checkColumnCount returns ErrColumnMismatch unless rows has exactly n columns
+func checkColumnCount(rows *sql.Rows, n int) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != n {
+		return ErrColumnMismatch
+	}
+	return nil
+}
+
+// LoadMaps executes the SelectBuilder and returns each row as a map of column name to value.
+// Values are converted to int64, float64, string, time.Time or nil based on the column's database type;
+// binary and unknown types are returned as []byte. DECIMAL values are returned as strings so they keep their precision.
+// Integer and BIT columns holding a value above math.MaxInt64 are returned as uint64.
+// MySQL's BOOL is TINYINT(1), so booleans are returned as int64 0 or 1.
+func (b *SelectBuilder) LoadMaps() ([]map[string]interface{}, error) {
+	return b.loadMaps("dbr.select.load_maps", false)
+}
+
+// LoadMap executes the SelectBuilder and returns the first row as a map of column name to value
+// Returns ErrNotFound if nothing was found
+func (b *SelectBuilder) LoadMap() (map[string]interface{}, error) {
+	maps, err := b.loadMaps("dbr.select.load_map", true)
+	if err != nil {
+		return nil, err
+	}
+	if len(maps) == 0 {
+		return nil, ErrNotFound
+	}
+	return maps[0], nil
+}
+
+func (b *SelectBuilder) loadMaps(eventName string, firstOnly bool) ([]map[string]interface{}, error) {
+	//
+	// Get full SQL
+	//
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return nil, b.errorKv(eventName+".to_sql", sql, err, nil)
+	}
+	fullSql, err := Interpolate(sql, args)
+	if err != nil {
+		return nil, b.errorKv(eventName+".interpolate", sql, err, nil)
+	}
+
+	// Start the timer:
+	startTime := time.Now()
+	defer func() { b.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), kvs{"sql": fullSql}) }()
+
+	// Run the query:
+	rows, err := b.runner.Query(fullSql)
+	if err != nil {
+		return nil, b.errorKv(eventName+".query", sql, err, kvs{"sql": fullSql})
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, b.errorKv(eventName+".rows.ColumnTypes", sql, err, kvs{"sql": fullSql})
+	}
+
+	// Scan every column into an interface{} and convert it afterwards
+	values := make([]interface{}, len(columnTypes))
+	holder := make([]interface{}, len(columnTypes))
+	for i := range values {
+		holder[i] = &values[i]
+	}
+
+	var maps []map[string]interface{}
+	for rows.Next() {
+		err = rows.Scan(holder...)
+		if err != nil {
+			return maps, b.errorKv(eventName+".scan", sql, err, kvs{"sql": fullSql})
+		}
+
+		m := make(map[string]interface{}, len(columnTypes))
+		for i, ct := range columnTypes {
+			v, err := convertColumnValue(ct.DatabaseTypeName(), values[i])
+			if err != nil {
+				return maps, b.errorKv(eventName+".convert", sql, err, kvs{"sql": fullSql, "column": ct.Name()})
+			}
+			m[ct.Name()] = v
+		}
+		maps = append(maps, m)
+
+		if firstOnly {
+			return maps, nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return maps, b.errorKv(eventName+".rows_err", sql, err, kvs{"sql": fullSql})
+	}
+
+	return maps, nil
+}
+
+// convertColumnValue turns the raw driver value for a column of the given database type into a natural Go type.
+// The mysql driver returns most values as []byte since we don't use prepared statements.
+func convertColumnValue(databaseTypeName string, v interface{}) (interface{}, error) {
+	raw, ok := v.([]byte)
+	if !ok {
+		// nil, or already converted by the driver (eg, time.Time with parseTime=true, or numbers it parsed itself)
+		rv := reflect.ValueOf(v)
+		switch {
+		case !rv.IsValid():
+			return nil, nil
+		case isInt(rv.Kind()):
+			return rv.Int(), nil
+		case isUint(rv.Kind()):
+			return unsignedColumnValue(rv.Uint()), nil
+		case rv.Kind() == reflect.Float32:
+			// Go through the shortest decimal so that eg 0.1 doesn't become 0.10000000149011612
+			return strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+		case rv.Kind() == reflect.Float64:
+			return rv.Float(), nil
+		}
+		return v, nil
+	}
+	str := string(raw)
+
+	typeName := strings.TrimPrefix(databaseTypeName, "UNSIGNED ")
+	switch typeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return i, nil
+		}
+		return strconv.ParseUint(str, 10, 64)
+	case "BIT":
+		// BIT(M) columns are sent as big-endian bytes
+		var u uint64
+		for _, c := range raw {
+			u = u<<8 | uint64(c)
+		}
+		return unsignedColumnValue(u), nil
+	case "FLOAT", "DOUBLE":
+		return strconv.ParseFloat(str, 64)
+	case "DECIMAL", "NUMERIC":
+		// Exact, eg for money, so it isn't rounded to a float64
+		return str, nil
+	case "DATETIME", "TIMESTAMP":
+		if str == "0000-00-00 00:00:00" {
+			return time.Time{}, nil
+		}
+		return time.ParseInLocation(timeFormat+".999999", str, time.UTC)
+	case "DATE":
+		if str == "0000-00-00" {
+			return time.Time{}, nil
+		}
+		return time.ParseInLocation("2006-01-02", str, time.UTC)
+	case "CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM", "SET", "JSON", "TIME":
+		return str, nil
+	}
+
+	return raw, nil
+}
+
+// unsignedColumnValue returns u as an int64 like other integer columns, unless it's too large for one
+func unsignedColumnValue(u uint64) interface{} {
+	if u > math.MaxInt64 {
+		return u
+	}
+	return int64(u)
+}
+
+// errorKv reports err to the EventReceiver and returns it wrapped in an *Error with sql, the statement built so far, if any
+func (b *SelectBuilder) errorKv(eventName, sql string, err error, kvs kvs) error {
+	if kvs == nil {
+		err = b.EventErr(eventName, err)
+	} else {
+		err = b.EventErrKv(eventName, err, kvs)
+	}
+	return newError("select", b.FromTable, sql, err)
+}
@@ -0,0 +1,217 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// LoadStructsMap executes the SelectBuilder and loads the resulting data into a map of structs keyed by key,
+// which is either a column of the result set or the name of a struct field.
+// dest must be a pointer to a map of structs or of pointers to structs, eg *map[int64]*User.
+// Number keys convert to any number type, and are formatted in decimal for string keys.
+// Returns ErrDuplicateMapKey if more than one row has the same key
+func (b *SelectBuilder) LoadStructsMap(dest interface{}, key string) (int, error) {
+	return b.loadKeyedStructs(dest, key, false)
+}
+
+// LoadGrouped executes the SelectBuilder and loads the resulting data into a map of slices of structs grouped by key,
+// which is either a column of the result set or the name of a struct field. Rows with a NULL key are grouped under the zero value.
+// dest must be a pointer to a map of slices of structs or of pointers to structs, eg *map[string][]*Order
+func (b *SelectBuilder) LoadGrouped(dest interface{}, key string) (int, error) {
+	return b.loadKeyedStructs(dest, key, true)
+}
+
+func (b *SelectBuilder) loadKeyedStructs(dest interface{}, key string, grouped bool) (int, error) {
+	//
+	// Validate the dest, and extract the reflection values we need.
+	//
+	valueOfDest := reflect.ValueOf(dest)
+	if valueOfDest.Kind() != reflect.Ptr || valueOfDest.Elem().Kind() != reflect.Map {
+		panic("invalid type passed to LoadStructsMap or LoadGrouped. Need a pointer to a map")
+	}
+
+	mapValue := valueOfDest.Elem()
+	mapType := mapValue.Type()
+
+	elemType := mapType.Elem()
+	if grouped {
+		if elemType.Kind() != reflect.Slice {
+			panic("invalid type passed to LoadGrouped. Need a pointer to a map of slices")
+		}
+		elemType = elemType.Elem()
+	}
+
+	recordType := elemType
+	recordTypeIsPtr := recordType.Kind() == reflect.Ptr
+	if recordTypeIsPtr {
+		recordType = recordType.Elem()
+	}
+
+	if recordType.Kind() != reflect.Struct {
+		panic("Elements need to be structures or pointers to structures")
+	}
+
+	if mapValue.IsNil() {
+		mapValue.Set(reflect.MakeMap(mapType))
+	}
+
+	r, err := b.queryRecords("dbr.select.load_keyed", recordType)
+	if err != nil {
+		return 0, err
+	}
+	defer r.close()
+
+	// Find the field holding the key
+	keyIndex, err := keyFieldIndex(recordType, r.columns, r.fieldMap, key, mapType.Key())
+	if err != nil {
+		return 0, r.errorKv("key", err, nil)
+	}
+
+	// Records are collected first, since preloads and AfterLoad have to run before value records are copied into the map
+	records := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(recordType)), 0, 0)
+	var keys []reflect.Value
+	seen := map[interface{}]bool{}
+
+	// Iterate over rows and scan their data into the structs
+	numberOfRowsReturned := 0
+	for r.next() {
+		// Create a new record to store our row:
+		pointerToNewRecord := reflect.New(recordType)
+		newRecord := reflect.Indirect(pointerToNewRecord)
+
+		// Load up our new structure with the row's values
+		if err := r.scan(newRecord); err != nil {
+			return numberOfRowsReturned, err
+		}
+
+		keyValue, err := mapKeyFor(newRecord, keyIndex, mapType.Key())
+		if err != nil {
+			return numberOfRowsReturned, r.errorKv("key", err, nil)
+		}
+		if !grouped {
+			if seen[keyValue.Interface()] || mapValue.MapIndex(keyValue).IsValid() {
+				return numberOfRowsReturned, r.errorKv("duplicate_key", ErrDuplicateMapKey, kvs{"key": fmt.Sprint(keyValue.Interface())})
+			}
+			seen[keyValue.Interface()] = true
+		}
+
+		records = reflect.Append(records, pointerToNewRecord)
+		keys = append(keys, keyValue)
+		numberOfRowsReturned++
+	}
+
+	if err := r.finish(records); err != nil {
+		return numberOfRowsReturned, err
+	}
+
+	// Add the records to the map
+	for i, keyValue := range keys {
+		recordValue := records.Index(i)
+		if !recordTypeIsPtr {
+			recordValue = recordValue.Elem()
+		}
+
+		if grouped {
+			existing := mapValue.MapIndex(keyValue)
+			if !existing.IsValid() {
+				existing = reflect.MakeSlice(mapType.Elem(), 0, 1)
+			}
+			mapValue.SetMapIndex(keyValue, reflect.Append(existing, recordValue))
+		} else {
+			mapValue.SetMapIndex(keyValue, recordValue)
+		}
+	}
+
+	return numberOfRowsReturned, nil
+}
+
+// mapKeyFor converts the key field of record to keyType. Valuers such as NullInt64 are keyed by their value.
+// A NULL key, or a key inside a nil nested struct, is the zero value of keyType
+func mapKeyFor(record reflect.Value, keyIndex []int, keyType reflect.Type) (reflect.Value, error) {
+	keyField, ok := fieldByIndexNoAlloc(record, keyIndex)
+	if !ok {
+		return reflect.Zero(keyType), nil
+	}
+	if keyValue, ok := convertMapKey(keyField, keyType); ok {
+		return keyValue, nil
+	}
+
+	v, err := keyField.Interface().(driver.Valuer).Value()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v == nil {
+		return reflect.Zero(keyType), nil
+	}
+	keyValue, ok := convertMapKey(reflect.ValueOf(v), keyType)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("dbr: can't use %T as a %s map key", v, keyType)
+	}
+	return keyValue, nil
+}
+
+// convertMapKey converts v to keyType if that keeps its meaning: numbers convert to other numbers and strings to
+// other strings, and integers are formatted in decimal for string keys. Go's conversion of an integer to a string
+// gives the character with that code instead, so it isn't used.
+func convertMapKey(v reflect.Value, keyType reflect.Type) (reflect.Value, bool) {
+	if !mapKeyConvertible(v.Type(), keyType) {
+		return reflect.Value{}, false
+	}
+	if keyType.Kind() == reflect.String {
+		switch kind := v.Kind(); {
+		case isInt(kind):
+			return reflect.ValueOf(strconv.FormatInt(v.Int(), 10)).Convert(keyType), true
+		case isUint(kind):
+			return reflect.ValueOf(strconv.FormatUint(v.Uint(), 10)).Convert(keyType), true
+		}
+	}
+	return v.Convert(keyType), true
+}
+
+// mapKeyConvertible reports whether convertMapKey converts values of type t to keyType
+func mapKeyConvertible(t reflect.Type, keyType reflect.Type) bool {
+	if t.AssignableTo(keyType) {
+		return true
+	}
+	kind, keyKind := t.Kind(), keyType.Kind()
+	switch {
+	case isNumber(kind) && isNumber(keyKind):
+		return true
+	case keyKind == reflect.String:
+		return kind == reflect.String || isInt(kind) || isUint(kind)
+	}
+	return false
+}
+
+func isNumber(k reflect.Kind) bool {
+	return isInt(k) || isUint(k) || isFloat(k)
+}
+
+// keyFieldIndex finds the index of the struct field for key, which is either a column of the result set or a field name,
+// and checks that the field can be converted to the map's key type
+func keyFieldIndex(recordType reflect.Type, columns []string, fieldMap [][]int, key string, keyType reflect.Type) ([]int, error) {
+	var keyIndex []int
+	for i, col := range columns {
+		if col == key {
+			keyIndex = fieldMap[i]
+			break
+		}
+	}
+	if keyIndex == nil {
+		if fieldStruct, ok := recordType.FieldByName(key); ok {
+			keyIndex = fieldStruct.Index
+		}
+	}
+	if keyIndex == nil {
+		return nil, fmt.Errorf("dbr: no struct field for map key %s", key)
+	}
+
+	fieldType := recordType.FieldByIndex(keyIndex).Type
+	if !mapKeyConvertible(fieldType, keyType) && !fieldType.Implements(typeOfValuer) {
+		return nil, fmt.Errorf("dbr: can't use %s field %s as a %s map key", fieldType, key, keyType)
+	}
+
+	return keyIndex, nil
+}
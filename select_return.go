@@ -64,3 +64,38 @@ func (b *SelectBuilder) ReturnStrings() ([]string, error) {
 	_, err := b.LoadValues(&v)
 	return v, err
 }
+
+// Exists executes the SelectBuilder wrapped in SELECT EXISTS(...) and returns whether any row matched.
+// The selected columns and ORDER BY clauses are ignored since they can't affect the answer,
+// except that the columns are kept when there's a HAVING clause, which can refer to their aliases.
+func (b *SelectBuilder) Exists() (bool, error) {
+	var sql string
+	var args []interface{}
+	var err error
+
+	if b.RawFullSql != "" {
+		sql, args, err = b.ToSql()
+	} else {
+		inner := *b
+		inner.IsDistinct = false
+		if len(b.HavingFragments) == 0 {
+			inner.Columns = []interface{}{"1"}
+		}
+		inner.OrderBys = nil
+		sql, args, err = inner.ToSql()
+	}
+	if err != nil {
+		return false, b.errorKv("dbr.select.exists.to_sql", sql, err, nil)
+	}
+
+	existsBuilder := &SelectBuilder{
+		Session:      b.Session,
+		runner:       b.runner,
+		RawFullSql:   "SELECT EXISTS(" + sql + ")",
+		RawArguments: args,
+	}
+
+	var v bool
+	err = existsBuilder.LoadValue(&v)
+	return v, err
+}
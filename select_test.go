@@ -1,7 +1,10 @@
 package dbr
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -56,10 +59,44 @@ func BenchmarkSelectFullSql(b *testing.B) {
 	}
 }
 
+func BenchmarkSelectLoadStructs(b *testing.B) {
+	s := createRealSessionWithFixtures()
+
+	// Load enough rows that mapping them matters as much as the round trip
+	insert := s.InsertInto("dbr_people").Columns("name", "email")
+	for i := 0; i < 100; i++ {
+		insert.Values("Barack", "obama@whitehouse.gov")
+	}
+	if _, err := insert.Exec(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var people []*dbrPerson
+		if _, err := s.Select("*").From("dbr_people").LoadStructs(&people); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSelectLoadStruct(b *testing.B) {
+	s := createRealSessionWithFixtures()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var person dbrPerson
+		if err := s.Select("*").From("dbr_people").Where("id = ?", 1).LoadStruct(&person); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestSelectBasicToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a", "b").From("c").Where("id = ?", 1).ToSql()
+	sql, args, err := s.Select("a", "b").From("c").Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT a, b FROM c WHERE (id = ?)")
 	assert.Equal(t, args, []interface{}{1})
@@ -68,7 +105,7 @@ func TestSelectBasicToSql(t *testing.T) {
 func TestSelectFullToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a", "b").
+	sql, args, err := s.Select("a", "b").
 		Distinct().
 		From("c").
 		Where("d = ? OR e = ?", 1, "wat").
@@ -81,30 +118,61 @@ func TestSelectFullToSql(t *testing.T) {
 		Limit(7).
 		Offset(8).
 		ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT DISTINCT a, b FROM c WHERE (d = ? OR e = ?) AND (`f` = ?) AND (`g` = ?) AND (`h` IN ?) GROUP BY i HAVING (j = k) ORDER BY l LIMIT 7 OFFSET 8")
 	assert.Equal(t, args, []interface{}{1, "wat", 2, 3, []int{4, 5, 6}})
 }
 
+func TestSelectBuildErrors(t *testing.T) {
+	s := createFakeSession()
+
+	_, _, err := s.Select("a").From("b").Where(5).ToSql()
+	assert.EqualError(t, err, "Invalid argument passed to Where. Pass a string or an Eq map.")
+
+	_, _, err = s.Select("a").ToSql()
+	assert.EqualError(t, err, "no table specified")
+
+	_, _, err = s.Select(5).From("b").ToSql()
+	assert.EqualError(t, err, "invalid column type. Pass a string or an Expr")
+
+	// Errors in subqueries carry over
+	_, _, err = s.Select("a", s.Select("COUNT(*)").Where("x = 1").As("c")).From("b").ToSql()
+	assert.EqualError(t, err, "no table specified")
+
+	var ids []int64
+	_, err = s.Select("id").From("b").Having(5).LoadValues(&ids)
+	assert.EqualError(t, err, "dbr: select b: Invalid argument passed to Where. Pass a string or an Eq map.")
+
+	// A subquery that failed to build panics when it's used
+	sub := s.Select("COUNT(*)").As("c")
+	s.PanicOnBuildError = true
+	assert.Panics(t, func() { s.Select("a").From("b").Where(5) })
+	assert.Panics(t, func() { s.Select("a").ToSql() })
+	assert.Panics(t, func() { s.Select("a", sub).From("b").ToSql() })
+}
+
 func TestSelectPaginateOrderDirToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a", "b").
+	sql, args, err := s.Select("a", "b").
 		From("c").
 		Where("d = ?", 1).
 		Paginate(1, 20).
 		OrderDir("id", false).
 		ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT a, b FROM c WHERE (d = ?) ORDER BY id DESC LIMIT 20 OFFSET 0")
 	assert.Equal(t, args, []interface{}{1})
 
-	sql, args = s.Select("a", "b").
+	sql, args, err = s.Select("a", "b").
 		From("c").
 		Where("d = ?", 1).
 		Paginate(3, 30).
 		OrderDir("id", true).
 		ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT a, b FROM c WHERE (d = ?) ORDER BY id ASC LIMIT 30 OFFSET 60")
 	assert.Equal(t, args, []interface{}{1})
@@ -113,7 +181,8 @@ func TestSelectPaginateOrderDirToSql(t *testing.T) {
 func TestSelectNoWhereSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a", "b").From("c").ToSql()
+	sql, args, err := s.Select("a", "b").From("c").ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT a, b FROM c")
 	assert.Equal(t, args, []interface{}(nil))
@@ -122,7 +191,8 @@ func TestSelectNoWhereSql(t *testing.T) {
 func TestSelectMultiHavingSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a", "b").From("c").Where("p = ?", 1).GroupBy("z").Having("z = ?", 2).Having("y = ?", 3).ToSql()
+	sql, args, err := s.Select("a", "b").From("c").Where("p = ?", 1).GroupBy("z").Having("z = ?", 2).Having("y = ?", 3).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT a, b FROM c WHERE (p = ?) GROUP BY z HAVING (z = ?) AND (y = ?)")
 	assert.Equal(t, args, []interface{}{1, 2, 3})
@@ -131,7 +201,8 @@ func TestSelectMultiHavingSql(t *testing.T) {
 func TestSelectMultiOrderSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a", "b").From("c").OrderBy("name ASC").OrderBy("id DESC").ToSql()
+	sql, args, err := s.Select("a", "b").From("c").OrderBy("name ASC").OrderBy("id DESC").ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "SELECT a, b FROM c ORDER BY name ASC, id DESC")
 	assert.Equal(t, args, []interface{}(nil))
@@ -140,11 +211,13 @@ func TestSelectMultiOrderSql(t *testing.T) {
 func TestSelectWhereMapSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a").From("b").Where(map[string]interface{}{"a": 1}).ToSql()
+	sql, args, err := s.Select("a").From("b").Where(map[string]interface{}{"a": 1}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (`a` = ?)")
 	assert.Equal(t, args, []interface{}{1})
 
-	sql, args = s.Select("a").From("b").Where(map[string]interface{}{"a": 1, "b": true}).ToSql()
+	sql, args, err = s.Select("a").From("b").Where(map[string]interface{}{"a": 1, "b": true}).ToSql()
+	assert.NoError(t, err)
 	if sql == "SELECT a FROM b WHERE (`a` = ?) AND (`b` = ?)" {
 		assert.Equal(t, args, []interface{}{1, true})
 	} else {
@@ -152,32 +225,38 @@ func TestSelectWhereMapSql(t *testing.T) {
 		assert.Equal(t, args, []interface{}{true, 1})
 	}
 
-	sql, args = s.Select("a").From("b").Where(map[string]interface{}{"a": nil}).ToSql()
+	sql, args, err = s.Select("a").From("b").Where(map[string]interface{}{"a": nil}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (`a` IS NULL)")
 	assert.Equal(t, args, []interface{}(nil))
 
-	sql, args = s.Select("a").From("b").Where(map[string]interface{}{"a": []int{1, 2, 3}}).ToSql()
+	sql, args, err = s.Select("a").From("b").Where(map[string]interface{}{"a": []int{1, 2, 3}}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (`a` IN ?)")
 	assert.Equal(t, args, []interface{}{[]int{1, 2, 3}})
 
-	sql, args = s.Select("a").From("b").Where(map[string]interface{}{"a": []int{1}}).ToSql()
+	sql, args, err = s.Select("a").From("b").Where(map[string]interface{}{"a": []int{1}}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (`a` = ?)")
 	assert.Equal(t, args, []interface{}{1})
 
 	// NOTE: a has no valid values, we want a query that returns nothing
-	sql, args = s.Select("a").From("b").Where(map[string]interface{}{"a": []int{}}).ToSql()
+	sql, args, err = s.Select("a").From("b").Where(map[string]interface{}{"a": []int{}}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (1=0)")
 	assert.Equal(t, args, []interface{}(nil))
 
 	var aval []int
-	sql, args = s.Select("a").From("b").Where(map[string]interface{}{"a": aval}).ToSql()
+	sql, args, err = s.Select("a").From("b").Where(map[string]interface{}{"a": aval}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (`a` IS NULL)")
 	assert.Equal(t, args, []interface{}(nil))
 
-	sql, args = s.Select("a").From("b").
+	sql, args, err = s.Select("a").From("b").
 		Where(map[string]interface{}{"a": []int(nil)}).
 		Where(map[string]interface{}{"b": false}).
 		ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT a FROM b WHERE (`a` IS NULL) AND (`b` = ?)")
 	assert.Equal(t, args, []interface{}{false})
 }
@@ -185,7 +264,8 @@ func TestSelectWhereMapSql(t *testing.T) {
 func TestSelectWhereEqSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Select("a").From("b").Where(Eq{"a": 1, "b": []int64{1, 2, 3}}).ToSql()
+	sql, args, err := s.Select("a").From("b").Where(Eq{"a": 1, "b": []int64{1, 2, 3}}).ToSql()
+	assert.NoError(t, err)
 	if sql == "SELECT a FROM b WHERE (`a` = ?) AND (`b` IN ?)" {
 		assert.Equal(t, args, []interface{}{1, []int64{1, 2, 3}})
 	} else {
@@ -197,16 +277,19 @@ func TestSelectWhereEqSql(t *testing.T) {
 func TestSelectBySql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.SelectBySql("SELECT * FROM users WHERE x = 1").ToSql()
+	sql, args, err := s.SelectBySql("SELECT * FROM users WHERE x = 1").ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT * FROM users WHERE x = 1")
 	assert.Equal(t, args, []interface{}(nil))
 
-	sql, args = s.SelectBySql("SELECT * FROM users WHERE x = ? AND y IN ?", 9, []int{5, 6, 7}).ToSql()
+	sql, args, err = s.SelectBySql("SELECT * FROM users WHERE x = ? AND y IN ?", 9, []int{5, 6, 7}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "SELECT * FROM users WHERE x = ? AND y IN ?")
 	assert.Equal(t, args, []interface{}{9, []int{5, 6, 7}})
 
 	// Doesn't fix shit if it's broken:
-	sql, args = s.SelectBySql("wat", 9, []int{5, 6, 7}).ToSql()
+	sql, args, err = s.SelectBySql("wat", 9, []int{5, 6, 7}).ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, "wat")
 	assert.Equal(t, args, []interface{}{9, []int{5, 6, 7}})
 }
@@ -214,11 +297,106 @@ func TestSelectBySql(t *testing.T) {
 func TestSelectVarieties(t *testing.T) {
 	s := createFakeSession()
 
-	sql, _ := s.Select("id, name, email").From("users").ToSql()
-	sql2, _ := s.Select("id", "name", "email").From("users").ToSql()
+	sql, _, err := s.Select("id, name, email").From("users").ToSql()
+	assert.NoError(t, err)
+	sql2, _, err := s.Select("id", "name", "email").From("users").ToSql()
+	assert.NoError(t, err)
 	assert.Equal(t, sql, sql2)
 }
 
+func TestSelectExprColumnsToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.Select("id", Expr("IF(score > ?, 'hot', 'cold') AS bucket", 10), Expr("DATE_FORMAT(created_at, ?)", "%Y")).
+		From("posts").
+		Where("user_id = ?", 1).
+		ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql, "SELECT id, IF(score > ?, 'hot', 'cold') AS bucket, DATE_FORMAT(created_at, ?) FROM posts WHERE (user_id = ?)")
+	assert.Equal(t, args, []interface{}{10, "%Y", 1})
+
+	sql, args, err = s.Select("id", s.Select("COUNT(*)").From("comments").Where("comments.post_id = posts.id AND spam = ?", false).As("comment_count")).
+		From("posts").
+		Where("user_id = ?", 1).
+		ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql, "SELECT id, (SELECT COUNT(*) FROM comments WHERE (comments.post_id = posts.id AND spam = ?)) AS comment_count FROM posts WHERE (user_id = ?)")
+	assert.Equal(t, args, []interface{}{false, 1})
+}
+
+func TestSelectHintsToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.Select("a", "b").
+		Distinct().
+		StraightJoin().
+		NoCache().
+		OptimizerHint("MAX_EXECUTION_TIME(1000)").
+		OptimizerHint("NO_ICP(c)").
+		From("c").
+		ForceIndex("idx_d").
+		IgnoreIndex("idx_e", "idx_f").
+		Where("d = ?", 1).
+		ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql, "SELECT /*+ MAX_EXECUTION_TIME(1000) NO_ICP(c) */ DISTINCT STRAIGHT_JOIN SQL_NO_CACHE a, b FROM c FORCE INDEX (idx_d) IGNORE INDEX (idx_e, idx_f) WHERE (d = ?)")
+	assert.Equal(t, args, []interface{}{1})
+
+	sql, _, err = s.Select("a").From("b").UseIndex("idx_c").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b USE INDEX (idx_c)")
+
+	// Joined tables are hinted in From
+	sql, args, err = s.Select("a.id").From("a USE INDEX (idx_a) JOIN b FORCE INDEX (idx_b) ON b.a_id = a.id").Where("b.c = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a.id FROM a USE INDEX (idx_a) JOIN b FORCE INDEX (idx_b) ON b.a_id = a.id WHERE (b.c = ?)")
+	assert.Equal(t, args, []interface{}{1})
+}
+
+func TestSelectClone(t *testing.T) {
+	s := createFakeSession()
+
+	base := s.Select("a").From("b").Where(Eq{"c": 1}).OrderBy("d")
+	clone := base.Clone().Where("e = ?", 2).OrderBy("f")
+	clone.WhereFragments[0].EqualityMap["c"] = 3
+
+	sql, args, err := base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (`c` = ?) ORDER BY d")
+	assert.Equal(t, args, []interface{}{1})
+
+	sql, args, err = clone.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (`c` = ?) AND (e = ?) ORDER BY d, f")
+	assert.Equal(t, args, []interface{}{3, 2})
+}
+
+func TestSelectImmutable(t *testing.T) {
+	s := createFakeSession()
+
+	base := s.Select("a").From("b").Where("c = ?", 1).Immutable()
+	first := base.Where("d = ?", 2).Paginate(2, 10)
+	second := base.OrderBy("e").Limit(1)
+
+	sql, args, err := base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (c = ?)")
+	assert.Equal(t, args, []interface{}{1})
+
+	sql, args, err = first.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (c = ?) AND (d = ?) LIMIT 10 OFFSET 10")
+	assert.Equal(t, args, []interface{}{1, 2})
+
+	sql, args, err = second.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "SELECT a FROM b WHERE (c = ?) ORDER BY e LIMIT 1")
+	assert.Equal(t, args, []interface{}{1})
+}
+
 func TestSelectLoadStructs(t *testing.T) {
 	s := createRealSessionWithFixtures()
 
@@ -246,6 +424,25 @@ func TestSelectLoadStructs(t *testing.T) {
 	// TODO: test map
 }
 
+func TestSelectLoadStructValues(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var people []dbrPerson
+	count, err := s.Select("id", "name", "email").From("dbr_people").OrderBy("id ASC").LoadStructs(&people)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+
+	assert.Equal(t, len(people), 2)
+	if len(people) == 2 {
+		assert.True(t, people[0].Id > 0)
+		assert.Equal(t, people[0].Name, "Jonathan")
+		assert.Equal(t, people[0].Email.String, "jonathan@uservoice.com")
+		assert.Equal(t, people[1].Name, "Dmitri")
+		assert.Equal(t, people[1].Email.String, "zavorotni@jadius.com")
+	}
+}
+
 func TestSelectLoadStruct(t *testing.T) {
 	s := createRealSessionWithFixtures()
 
@@ -280,6 +477,65 @@ func TestSelectBySqlLoadStructs(t *testing.T) {
 	}
 }
 
+func TestSelectLoadStrict(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var people []*dbrPerson
+	_, err := s.Select("id", "name", "email").From("dbr_people").Strict().LoadStructs(&people)
+	var mappingErr *MappingError
+	assert.True(t, errors.As(err, &mappingErr))
+	assert.Equal(t, mappingErr, &MappingError{UnfilledFields: []string{"Key"}})
+
+	var person dbrPerson
+	err = s.Select("id", "name", "email", "`key`", "1 AS extra").From("dbr_people").Strict().Limit(1).LoadStruct(&person)
+	assert.True(t, errors.As(err, &mappingErr))
+	assert.Equal(t, mappingErr, &MappingError{UnmatchedColumns: []string{"extra"}})
+	assert.Equal(t, err.Error(), "dbr: select dbr_people: strict mapping failed: columns without a matching field: extra")
+
+	s.StrictMapping = true
+	count, err := s.Select("*").From("dbr_people").LoadStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+}
+
+func TestCheckStrictMapping(t *testing.T) {
+	type audit struct {
+		CreatedAt time.Time
+		UpdatedBy NullString
+	}
+	type record struct {
+		Id      int64
+		Ignored string `db:"-"`
+		Audit   audit
+	}
+
+	recordType := reflect.TypeOf(record{})
+	columns := []string{"id", "created_at", "wat"}
+	fieldMap := [][]int{{0}, {2, 0}, nil}
+
+	err := checkStrictMapping(recordType, columns, fieldMap)
+	assert.Equal(t, err, &MappingError{UnmatchedColumns: []string{"wat"}, UnfilledFields: []string{"Audit.UpdatedBy"}})
+	assert.Equal(t, err.Error(), "dbr: strict mapping failed: columns without a matching field: wat; fields not returned by the query: Audit.UpdatedBy")
+
+	err = checkStrictMapping(recordType, []string{"id", "created_at", "updated_by"}, [][]int{{0}, {2, 0}, {2, 1}})
+	assert.NoError(t, err)
+
+	// An embedded field shadowed by one of the outer struct can't be filled, so it isn't missing
+	type base struct {
+		Id   int64
+		Name string
+	}
+	type shadowing struct {
+		base
+		Id int64
+	}
+	err = checkStrictMapping(reflect.TypeOf(shadowing{}), []string{"id", "name"}, [][]int{{1}, {0, 1}})
+	assert.NoError(t, err)
+
+	err = checkStrictMapping(reflect.TypeOf(shadowing{}), []string{"id"}, [][]int{{1}})
+	assert.Equal(t, err, &MappingError{UnfilledFields: []string{"base.Name"}})
+}
+
 func TestSelectLoadValue(t *testing.T) {
 	s := createRealSessionWithFixtures()
 
@@ -314,6 +570,139 @@ func TestSelectLoadValues(t *testing.T) {
 	assert.Equal(t, ids, []int64{1})
 }
 
+func TestSelectLoadMultipleValues(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var minId, maxId int64
+	err := s.Select("MIN(id)", "MAX(id)").From("dbr_people").LoadValue(&minId, &maxId)
+
+	assert.NoError(t, err)
+	assert.Equal(t, minId, int64(1))
+	assert.Equal(t, maxId, int64(2))
+
+	var ids []int64
+	var names []string
+	count, err := s.Select("id", "name").From("dbr_people").OrderBy("id").LoadValues(&ids, &names)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, ids, []int64{1, 2})
+	assert.Equal(t, names, []string{"Jonathan", "Dmitri"})
+
+	// The number of destinations must match the number of columns
+	err = s.Select("id", "name").From("dbr_people").LoadValue(&minId)
+	assert.True(t, errors.Is(err, ErrColumnMismatch))
+
+	_, err = s.Select("id").From("dbr_people").LoadValues(&ids, &names)
+	assert.True(t, errors.Is(err, ErrColumnMismatch))
+}
+
+func TestSelectLoadPointerValues(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var emails []*string
+	count, err := s.Select("email").From("dbr_people").OrderBy("id ASC").LoadValues(&emails)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	if len(emails) == 2 {
+		assert.Equal(t, *emails[0], "jonathan@uservoice.com")
+		assert.Equal(t, *emails[1], "zavorotni@jadius.com")
+	}
+
+	var keys []*string
+	count, err = s.Select("`key`").From("dbr_people").LoadValues(&keys)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, keys, []*string{nil, nil})
+}
+
+func TestSelectLoadMaps(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	_, err := s.InsertInto("null_types").Columns("string_val", "int64_val", "float64_val", "time_val").
+		Values("wow", 42, 1.5, time.Date(2014, 3, 4, 5, 6, 7, 0, time.UTC)).Exec()
+	assert.NoError(t, err)
+
+	maps, err := s.Select("string_val", "int64_val", "float64_val", "time_val", "bool_val").From("null_types").LoadMaps()
+	assert.NoError(t, err)
+	assert.Equal(t, len(maps), 1)
+	if len(maps) == 1 {
+		assert.Equal(t, maps[0]["string_val"], "wow")
+		assert.Equal(t, maps[0]["int64_val"], int64(42))
+		assert.Equal(t, maps[0]["float64_val"], float64(1.5))
+		assert.Equal(t, maps[0]["time_val"], time.Date(2014, 3, 4, 5, 6, 7, 0, time.UTC))
+		assert.Equal(t, maps[0]["bool_val"], nil)
+	}
+
+	m, err := s.Select("id", "name").From("dbr_people").Where("name = ?", "Dmitri").LoadMap()
+	assert.NoError(t, err)
+	assert.Equal(t, m["name"], "Dmitri")
+	assert.True(t, m["id"].(int64) > 0)
+
+	_, err = s.Select("id").From("dbr_people").Where("name = ?", "Nobody").LoadMap()
+	assert.Equal(t, err, ErrNotFound)
+}
+
+func TestConvertColumnValue(t *testing.T) {
+	v, err := convertColumnValue("UNSIGNED BIGINT", []byte("18446744073709551615"))
+	assert.NoError(t, err)
+	assert.Equal(t, v, uint64(18446744073709551615))
+
+	// Too precise for a float64
+	v, err = convertColumnValue("DECIMAL", []byte("12345678901234567.89"))
+	assert.NoError(t, err)
+	assert.Equal(t, v, "12345678901234567.89")
+
+	v, err = convertColumnValue("DATETIME", []byte("2014-03-04 05:06:07"))
+	assert.NoError(t, err)
+	assert.Equal(t, v, time.Date(2014, 3, 4, 5, 6, 7, 0, time.UTC))
+
+	v, err = convertColumnValue("DATE", []byte("2014-03-04"))
+	assert.NoError(t, err)
+	assert.Equal(t, v, time.Date(2014, 3, 4, 0, 0, 0, 0, time.UTC))
+
+	v, err = convertColumnValue("BIT", []byte{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, v, int64(258))
+
+	v, err = convertColumnValue("BIT", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	assert.NoError(t, err)
+	assert.Equal(t, v, uint64(18446744073709551615))
+
+	// Values the driver already parsed are widened like the ones it didn't
+	v, err = convertColumnValue("FLOAT", float32(0.1))
+	assert.NoError(t, err)
+	assert.Equal(t, v, 0.1)
+
+	v, err = convertColumnValue("INT", int32(7))
+	assert.NoError(t, err)
+	assert.Equal(t, v, int64(7))
+
+	v, err = convertColumnValue("UNSIGNED BIGINT", uint64(7))
+	assert.NoError(t, err)
+	assert.Equal(t, v, int64(7))
+
+	v, err = convertColumnValue("BLOB", []byte{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, v, []byte{1, 2})
+
+	_, err = convertColumnValue("INT", []byte("nope"))
+	assert.NotEqual(t, err, nil)
+}
+
+func TestSelectLoadExprColumn(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var greetings []string
+	count, err := s.Select(Expr("CONCAT(?, name)", "hi ")).From("dbr_people").Where("name = ?", "Dmitri").LoadValues(&greetings)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 1)
+	assert.Equal(t, greetings, []string{"hi Dmitri"})
+}
+
 func TestSelectReturn(t *testing.T) {
 	s := createRealSessionWithFixtures()
 
@@ -334,4 +723,100 @@ func TestSelectReturn(t *testing.T) {
 	assert.Equal(t, counts, []int64{2})
 }
 
+func TestSelectExists(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	found, err := s.Select("id", "name").From("dbr_people").Where("email = ?", "jonathan@uservoice.com").OrderBy("id").Exists()
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = s.Select("*").From("dbr_people").Where("email = ?", "dontexist@uservoice.com").Exists()
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = s.SelectBySql("SELECT id FROM dbr_people WHERE name = ?", "Dmitri").Exists()
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	// HAVING can refer to the aliases of the selected columns, so they're kept
+	found, err = s.Select("name", "COUNT(*) AS n").From("dbr_people").GroupBy("name").Having("n > ?", 0).Exists()
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = s.Select("name", "COUNT(*) AS n").From("dbr_people").GroupBy("name").Having("n > ?", 1).Exists()
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestSelectLoadStructsMap(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var byId map[int64]*dbrPerson
+	count, err := s.Select("*").From("dbr_people").LoadStructsMap(&byId, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, len(byId), 2)
+	for id, person := range byId {
+		assert.Equal(t, person.Id, id)
+	}
+
+	// Keyed by struct field name, into a map of values
+	var byName map[string]dbrPerson
+	count, err = s.Select("id", "name").From("dbr_people").LoadStructsMap(&byName, "Name")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, byName["Dmitri"].Name, "Dmitri")
+
+	var byPerson map[int64]*dbrAddress
+	_, err = s.Select("*").From("dbr_addresses").Where("person_id = 1").LoadStructsMap(&byPerson, "person_id")
+	assert.True(t, errors.Is(err, ErrDuplicateMapKey))
+
+	var bogus map[int64]*dbrPerson
+	_, err = s.Select("*").From("dbr_people").LoadStructsMap(&bogus, "bogus")
+	assert.Error(t, err)
+
+	_, err = s.Select("*").From("dbr_people").LoadStructsMap(&bogus, "name")
+	assert.Error(t, err)
+}
+
+func TestSelectLoadStructsMapStringKeys(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	// Integer keys are formatted in decimal, not converted to the character with that code
+	var byId map[string]*dbrPerson
+	_, err := s.Select("*").From("dbr_people").LoadStructsMap(&byId, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, len(byId), 2)
+	if assert.NotNil(t, byId["1"]) {
+		assert.Equal(t, byId["1"].Name, "Jonathan")
+	}
+
+	var byPerson map[string][]*dbrAddress
+	_, err = s.Select("*").From("dbr_addresses").LoadGrouped(&byPerson, "person_id")
+	assert.NoError(t, err)
+	assert.Equal(t, len(byPerson["1"]), 2)
+	assert.Equal(t, len(byPerson[""]), 1)
+}
+
+func TestSelectLoadGrouped(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var byPerson map[int64][]*dbrAddress
+	count, err := s.Select("*").From("dbr_addresses").OrderBy("id").LoadGrouped(&byPerson, "person_id")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 3)
+	assert.Equal(t, len(byPerson[1]), 2)
+	if len(byPerson[1]) == 2 {
+		assert.Equal(t, byPerson[1][0].City, "Boulder")
+		assert.Equal(t, byPerson[1][1].City, "San Francisco")
+	}
+	assert.Equal(t, len(byPerson[0]), 1)
+
+	var byCity map[string][]dbrAddress
+	count, err = s.Select("id", "city").From("dbr_addresses").LoadGrouped(&byCity, "City")
+	assert.NoError(t, err)
+	assert.Equal(t, count, 3)
+	assert.Equal(t, len(byCity), 3)
+}
+
 // Series of tests that test mapping struct fields to columns
@@ -1,75 +1,411 @@
 package dbr
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 )
 
 var destDummy interface{}
 
-type fieldMapQueueElement struct {
-	Type reflect.Type
-	Idxs []int
-}
+// Field indexes are cached, since walking a struct with reflection on every query (and for every record
+// of a multi-record insert) is expensive. The caches are keyed by type only, so they don't grow with the
+// column sets queried, and NameMapping must be set before the first query is run.
+var (
+	fieldIndexCache      = map[reflect.Type]map[string][]int{}
+	fieldIndexCacheMutex sync.RWMutex
+
+	fieldConvertersCache      = map[reflect.Type]map[string]Converter{}
+	fieldConvertersCacheMutex sync.RWMutex
+
+	insertColumnsCache      = map[reflect.Type][]insertColumn{}
+	insertColumnsCacheMutex sync.RWMutex
+)
 
-// recordType is the type of a structure
-func (sess *Session) calculateFieldMap(recordType reflect.Type, columns []string, requireAllColumns bool) ([][]int, error) {
+// fieldMapping is the mapping of a set of columns to the fields of a struct, looked up in the cached field index
+type fieldMapping struct {
 	// each value is either the slice to get to the field via FieldByIndex(index []int) in the record, or nil if we don't want to map it to the structure.
-	lenColumns := len(columns)
-	fieldMap := make([][]int, lenColumns)
+	fieldMap [][]int
+
+	// the converter of each mapped field, or nil if there aren't any
+	converters []Converter
+}
 
+// calculateFieldMapping maps columns to the fields of recordType, a structure.
+// The field indexes in the returned field map are shared between callers and must not be modified.
+func (sess *Session) calculateFieldMapping(recordType reflect.Type, columns []string, requireAllColumns bool) (*fieldMapping, error) {
+	index := fieldIndexFor(recordType)
+	converters := fieldConvertersFor(recordType)
+
+	mapping := &fieldMapping{fieldMap: make([][]int, len(columns))}
 	for i, col := range columns {
-		fieldMap[i] = nil
+		mapping.fieldMap[i] = index[col]
+		if c, ok := converters[col]; ok {
+			if mapping.converters == nil {
+				mapping.converters = make([]Converter, len(columns))
+			}
+			mapping.converters[i] = c
+		}
+	}
+
+	if requireAllColumns {
+		for i, fieldIndex := range mapping.fieldMap {
+			if fieldIndex == nil {
+				return nil, errors.New(fmt.Sprint("couldn't find match for column ", columns[i]))
+			}
+		}
+	}
 
-		queue := []fieldMapQueueElement{fieldMapQueueElement{Type: recordType, Idxs: nil}}
+	return mapping, nil
+}
+
+// fieldIndexFor returns a map of column name to the index of the struct field it maps to
+func fieldIndexFor(recordType reflect.Type) map[string][]int {
+	fieldIndexCacheMutex.RLock()
+	index, ok := fieldIndexCache[recordType]
+	fieldIndexCacheMutex.RUnlock()
 
-	QueueLoop:
-		for len(queue) > 0 {
-			curEntry := queue[0]
-			queue = queue[1:]
+	if ok {
+		return index
+	}
 
-			curType := curEntry.Type
-			curIdxs := curEntry.Idxs
-			lenFields := curType.NumField()
+	index = buildFieldIndex(recordType)
+
+	fieldIndexCacheMutex.Lock()
+	fieldIndexCache[recordType] = index
+	fieldIndexCacheMutex.Unlock()
+
+	return index
+}
+
+// dbTag is a parsed db struct tag: a column name followed by comma-separated options,
+// eg `db:"customer,prefix=customer_"`
+type dbTag struct {
+	Name    string
+	Options []string
+}
 
+func parseDbTag(tag string) dbTag {
+	parts := strings.Split(tag, ",")
+	return dbTag{Name: parts[0], Options: parts[1:]}
+}
+
+// Option returns the value of a key=value option, or "" for a flag option, and whether the option is present
+func (t dbTag) Option(key string) (string, bool) {
+	for _, opt := range t.Options {
+		if opt == key {
+			return "", true
+		}
+		if strings.HasPrefix(opt, key+"=") {
+			return opt[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// structField is a field of a record, possibly inside nested structs, that can be mapped to a column
+type structField struct {
+	Column    string // column name, from the db tag or NameMapping, with any prefixes of enclosing structs
+	Qualified string // column name qualified by enclosing named structs, eg "customer.id", or "" at the top level
+	Tagged    bool   // whether the column name came from a db tag
+	Idxs      []int  // index path for FieldByIndex
+	Path      string // dotted Go path, eg "Author.Email", for messages
+	Nested    bool   // whether the field is a struct (or pointer to one) whose fields are mapped too
+	Promoted  bool   // whether the field is reached only through embedded structs, like Go's promoted fields
+}
+
+// structFieldsByDepth walks recordType breadth-first and returns its mappable fields grouped by depth.
+// Nested structs are descended into, whether they're embedded or named, values or pointers,
+// unless they are values in their own right: sql.Scanner implementations and time.Time.
+// Fields tagged db:"-" are skipped entirely, and fields with a converter, eg `db:"settings,json"`, are never descended into.
+//
+// The fields of a nested struct tagged with a prefix option, eg `db:"customer,prefix=customer_"`, map to
+// prefixed columns (customer_id) instead of their plain names. Fields of named nested structs can also be
+// selected with qualified labels like "customer.id", eg SELECT customers.id AS `customer.id`.
+func structFieldsByDepth(recordType reflect.Type) [][]structField {
+	type queueElement struct {
+		Type      reflect.Type
+		Idxs      []int
+		Path      string
+		Prefix    string
+		Qualifier string
+		Embedded  bool
+		Ancestors []reflect.Type
+	}
+
+	var depths [][]structField
+	level := []queueElement{{Type: recordType, Embedded: true, Ancestors: []reflect.Type{recordType}}}
+
+	for len(level) > 0 {
+		var fields []structField
+		var next []queueElement
+
+		for _, cur := range level {
+			lenFields := cur.Type.NumField()
 			for j := 0; j < lenFields; j++ {
-				fieldStruct := curType.Field(j)
+				fieldStruct := cur.Type.Field(j)
+				tag := parseDbTag(fieldStruct.Tag.Get("db"))
+				if tag.Name == "-" {
+					continue
+				}
 
-				// Skip unexported field
-				if len(fieldStruct.PkgPath) != 0 {
+				// Skip unexported fields, except embedded struct values whose exported fields are promoted
+				unexported := len(fieldStruct.PkgPath) != 0
+				if unexported && !(fieldStruct.Anonymous && fieldStruct.Type.Kind() == reflect.Struct) {
 					continue
 				}
 
-				name := fieldStruct.Tag.Get("db")
-				if name != "-" {
-					if name == "" {
-						name = NameMapping(fieldStruct.Name)
+				idxs := make([]int, len(cur.Idxs), len(cur.Idxs)+1)
+				copy(idxs, cur.Idxs)
+				idxs = append(idxs, j)
+
+				name := tag.Name
+				if name == "" {
+					name = NameMapping(fieldStruct.Name)
+				}
+
+				field := structField{Column: cur.Prefix + name, Tagged: tag.Name != "", Idxs: idxs, Path: cur.Path + fieldStruct.Name, Promoted: cur.Embedded}
+				if cur.Qualifier != "" {
+					field.Qualified = cur.Qualifier + name
+				}
+
+				// Don't follow pointer cycles like Parent *Node
+				if nestedType := nestedStructType(fieldStruct.Type); nestedType != nil && !containsType(cur.Ancestors, nestedType) && converterFor(tag) == nil {
+					field.Nested = true
+
+					// Embedded structs are transparent, like Go's promoted fields
+					element := queueElement{
+						Type:      nestedType,
+						Idxs:      idxs,
+						Path:      field.Path + ".",
+						Prefix:    cur.Prefix,
+						Qualifier: cur.Qualifier,
+						Embedded:  cur.Embedded && fieldStruct.Anonymous,
+						Ancestors: append(cur.Ancestors[:len(cur.Ancestors):len(cur.Ancestors)], nestedType),
 					}
-					if name == col {
-						fieldMap[i] = append(curIdxs, j)
-						break QueueLoop
+					if prefix, ok := tag.Option("prefix"); ok {
+						element.Prefix += prefix
 					}
+					if !fieldStruct.Anonymous {
+						element.Qualifier += name + "."
+					}
+					next = append(next, element)
+				}
+
+				if !unexported {
+					fields = append(fields, field)
 				}
+			}
+		}
+
+		depths = append(depths, fields)
+		level = next
+	}
+
+	return depths
+}
+
+// nestedStructType returns the struct type to descend into for a field of type t, or nil if t is a plain value
+func nestedStructType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == typeOfTime || reflect.PtrTo(t).Implements(typeOfScanner) {
+		return nil
+	}
+	return t
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// buildFieldIndex maps each column name to a field following Go's rules for promoted fields:
+// the shallowest field wins, and if there are several at that depth, one promoted through embedded structs
+// wins over those of named nested structs, then the one with a db tag wins.
+// If that's still ambiguous, the column isn't mapped at all.
+func buildFieldIndex(recordType reflect.Type) map[string][]int {
+	index := map[string][]int{}
+	resolved := map[string]bool{}
+	depths := structFieldsByDepth(recordType)
+
+	for _, fields := range depths {
+		candidates := map[string][]structField{}
+		for _, field := range fields {
+			if !resolved[field.Column] {
+				candidates[field.Column] = append(candidates[field.Column], field)
+			}
+		}
+
+		for column, fields := range candidates {
+			resolved[column] = true
+			if field, ok := dominantField(fields); ok {
+				index[column] = field.Idxs
+			}
+		}
+	}
+
+	// Qualified names are unique, so they don't need shadowing rules; they just can't replace a plain name
+	for _, fields := range depths {
+		for _, field := range fields {
+			if _, ok := index[field.Qualified]; field.Qualified != "" && !ok {
+				index[field.Qualified] = field.Idxs
+			}
+		}
+	}
+
+	return index
+}
+
+func dominantField(fields []structField) (structField, bool) {
+	// Fields promoted through embedded structs win over fields of named nested structs, which Go doesn't promote
+	var promoted []structField
+	for _, field := range fields {
+		if field.Promoted {
+			promoted = append(promoted, field)
+		}
+	}
+	if len(promoted) > 0 {
+		fields = promoted
+	}
+
+	if len(fields) == 1 {
+		return fields[0], true
+	}
+
+	var tagged []structField
+	for _, field := range fields {
+		if field.Tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+
+	return structField{}, false
+}
 
-				if fieldStruct.Type.Kind() == reflect.Struct {
-					var idxs2 []int
-					copy(idxs2, curIdxs)
-					idxs2 = append(idxs2, j)
-					queue = append(queue, fieldMapQueueElement{Type: fieldStruct.Type, Idxs: idxs2})
+// MappingError is returned in strict mapping mode when the columns of a result set
+// and the fields of the destination struct don't line up
+type MappingError struct {
+	UnmatchedColumns []string // columns in the result set that no struct field maps to
+	UnfilledFields   []string // struct fields, other than db:"-", that no column maps to
+}
+
+func (e *MappingError) Error() string {
+	var parts []string
+	if len(e.UnmatchedColumns) > 0 {
+		parts = append(parts, "columns without a matching field: "+strings.Join(e.UnmatchedColumns, ", "))
+	}
+	if len(e.UnfilledFields) > 0 {
+		parts = append(parts, "fields not returned by the query: "+strings.Join(e.UnfilledFields, ", "))
+	}
+	return "dbr: strict mapping failed: " + strings.Join(parts, "; ")
+}
+
+var typeOfScanner = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var typeOfValuer = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// checkStrictMapping returns a *MappingError if any column is unmapped or any mappable field of recordType is left unfilled
+func checkStrictMapping(recordType reflect.Type, columns []string, fieldMap [][]int) error {
+	var unmatchedColumns []string
+	for i, fieldIndex := range fieldMap {
+		if fieldIndex == nil {
+			unmatchedColumns = append(unmatchedColumns, columns[i])
+		}
+	}
+
+	// Collect the plain (non-nested) fields that aren't mapped, directly or through a nested struct mapped as a whole.
+	// Fields that are shadowed or ambiguous can't be mapped by any column, so they don't count.
+	index := fieldIndexFor(recordType)
+	var unfilledFields []string
+	for _, fields := range structFieldsByDepth(recordType) {
+	FieldLoop:
+		for _, field := range fields {
+			if field.Nested {
+				continue
+			}
+			mappable := reflect.DeepEqual(index[field.Column], field.Idxs)
+			mappable = mappable || field.Qualified != "" && reflect.DeepEqual(index[field.Qualified], field.Idxs)
+			if !mappable {
+				continue
+			}
+			for _, fieldIndex := range fieldMap {
+				if fieldIndex != nil && isIndexPrefix(fieldIndex, field.Idxs) {
+					continue FieldLoop
 				}
 			}
+			unfilledFields = append(unfilledFields, field.Path)
 		}
+	}
 
-		if requireAllColumns && fieldMap[i] == nil {
-			return nil, errors.New(fmt.Sprint("couldn't find match for column ", col))
+	if len(unmatchedColumns) > 0 || len(unfilledFields) > 0 {
+		return &MappingError{UnmatchedColumns: unmatchedColumns, UnfilledFields: unfilledFields}
+	}
+	return nil
+}
+
+// isIndexPrefix returns true if prefix is index, or the index of a struct that index is inside of
+func isIndexPrefix(prefix, index []int) bool {
+	if len(prefix) > len(index) {
+		return false
+	}
+	for i, x := range prefix {
+		if index[i] != x {
+			return false
 		}
 	}
+	return true
+}
 
-	return fieldMap, nil
+// deferredColumns tracks the columns of a result set that can't be scanned straight into their fields.
+// They are scanned into placeholders first, and then:
+//   - columns that map to fields inside pointers to nested structs are scanned again into the fields of
+//     the allocated structs, so that a pointer can be left nil when all of its columns are NULL.
+//   - columns whose fields have a converter are converted into their fields.
+type deferredColumns struct {
+	pointers    []bool
+	converters  []Converter
+	values      []interface{}
+	anyPointers bool
 }
 
-func (sess *Session) prepareHolderFor(record reflect.Value, fieldMap [][]int, holder []interface{}) ([]interface{}, error) {
+func newDeferredColumns(recordType reflect.Type, mapping *fieldMapping) *deferredColumns {
+	fieldMap := mapping.fieldMap
+	d := &deferredColumns{
+		pointers:   make([]bool, len(fieldMap)),
+		converters: mapping.converters,
+		values:     make([]interface{}, len(fieldMap)),
+	}
+	if d.converters == nil {
+		d.converters = make([]Converter, len(fieldMap))
+	}
+	for i, fieldIndex := range fieldMap {
+		t := recordType
+		for _, x := range fieldIndex {
+			if t.Kind() == reflect.Ptr {
+				d.pointers[i] = true
+				d.anyPointers = true
+				break
+			}
+			t = t.Field(x).Type
+		}
+	}
+	return d
+}
+
+func (sess *Session) prepareHolderFor(record reflect.Value, fieldMap [][]int, holder []interface{}, deferred *deferredColumns) ([]interface{}, error) {
 	// Given a query and given a structure (field list), there's 2 sets of fields.
 	// Take the intersection. We can fill those in. great.
 	// For fields in the structure that aren't in the query, we'll let that slide if db:"-"
@@ -79,6 +415,8 @@ func (sess *Session) prepareHolderFor(record reflect.Value, fieldMap [][]int, ho
 	for i, fieldIndex := range fieldMap {
 		if fieldIndex == nil {
 			holder[i] = &destDummy
+		} else if deferred.pointers[i] || deferred.converters[i] != nil {
+			holder[i] = &deferred.values[i]
 		} else {
 			field := record.FieldByIndex(fieldIndex)
 			holder[i] = field.Addr().Interface()
@@ -88,22 +426,172 @@ func (sess *Session) prepareHolderFor(record reflect.Value, fieldMap [][]int, ho
 	return holder, nil
 }
 
+// scanDeferredColumns fills in the fields of the deferred columns of the current row.
+// Structs behind pointers are allocated for the columns that aren't NULL; pointers that are already allocated are scanned into as-is.
+func (sess *Session) scanDeferredColumns(rows *sql.Rows, record reflect.Value, fieldMap [][]int, holder []interface{}, deferred *deferredColumns) error {
+	if deferred.anyPointers {
+		for i, fieldIndex := range fieldMap {
+			holder[i] = &destDummy
+			if !deferred.pointers[i] || deferred.converters[i] != nil {
+				continue
+			}
+			if _, allocated := fieldByIndexNoAlloc(record, fieldIndex); deferred.values[i] != nil || allocated {
+				holder[i] = fieldByIndexAlloc(record, fieldIndex).Addr().Interface()
+			}
+			deferred.values[i] = nil
+		}
+
+		if err := rows.Scan(holder...); err != nil {
+			return err
+		}
+	}
+
+	for i, converter := range deferred.converters {
+		if converter == nil {
+			continue
+		}
+		value := deferred.values[i]
+		deferred.values[i] = nil
+
+		field, ok := fieldByIndexNoAlloc(record, fieldMap[i])
+		if !ok {
+			if value == nil {
+				continue
+			}
+			field = fieldByIndexAlloc(record, fieldMap[i])
+		}
+
+		if value == nil {
+			field.Set(reflect.Zero(field.Type()))
+		} else if err := converter.FromDb(value, field.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (sess *Session) valuesFor(recordType reflect.Type, record reflect.Value, columns []string) ([]interface{}, error) {
-	fieldMap, err := sess.calculateFieldMap(recordType, columns, true)
+	// Every column is mapped, since we require all of them
+	mapping, err := sess.calculateFieldMapping(recordType, columns, true)
 	if err != nil {
-		fmt.Println("err: calc field map")
 		return nil, err
 	}
 
 	values := make([]interface{}, len(columns))
-	for i, fieldIndex := range fieldMap {
-		if fieldIndex == nil {
-			panic("wtf bro")
-		} else {
-			field := record.FieldByIndex(fieldIndex)
+	for i, fieldIndex := range mapping.fieldMap {
+		// A nil pointer to a nested struct leaves the value nil, which is inserted as NULL
+		if field, ok := fieldByIndexNoAlloc(record, fieldIndex); ok {
 			values[i] = field.Interface()
+			if mapping.converters != nil && mapping.converters[i] != nil {
+				if values[i], err = mapping.converters[i].ToDb(values[i]); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
 	return values, nil
 }
+
+// insertColumn is a column InsertBuilder derives from a record's fields when no columns are given
+type insertColumn struct {
+	Name      string
+	Idxs      []int
+	Omittable bool // an auto-increment key or omitempty field, left to its default when zero
+}
+
+// insertColumnsFor returns the columns that the fields of recordType map to, in the order the fields are declared.
+// Only top-level fields and fields promoted from embedded structs are columns of the table, so named nested structs,
+// eg the joined rows of a `db:"customer,prefix=customer_"` field, are left out. The Id int64 field that InsertBuilder
+// sets from LastInsertId, fields tagged autoincrement and fields tagged omitempty are omittable.
+func insertColumnsFor(recordType reflect.Type) []insertColumn {
+	insertColumnsCacheMutex.RLock()
+	columns, ok := insertColumnsCache[recordType]
+	insertColumnsCacheMutex.RUnlock()
+
+	if ok {
+		return columns
+	}
+
+	var idIdxs []int
+	if idStruct, ok := recordType.FieldByName("Id"); ok && idStruct.Type.Kind() == reflect.Int64 {
+		idIdxs = idStruct.Index
+	}
+
+	index := fieldIndexFor(recordType)
+	for _, fields := range structFieldsByDepth(recordType) {
+		for _, field := range fields {
+			// Skip nested structs, the fields of named nested structs, and fields shadowed by another one with the same column
+			if field.Nested || !field.Promoted || !reflect.DeepEqual(index[field.Column], field.Idxs) {
+				continue
+			}
+
+			tag := parseDbTag(recordType.FieldByIndex(field.Idxs).Tag.Get("db"))
+			_, autoIncrement := tag.Option("autoincrement")
+			_, omitEmpty := tag.Option("omitempty")
+			omittable := autoIncrement || omitEmpty || reflect.DeepEqual(field.Idxs, idIdxs)
+			columns = append(columns, insertColumn{Name: field.Column, Idxs: field.Idxs, Omittable: omittable})
+		}
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		a, b := columns[i].Idxs, columns[j].Idxs
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	insertColumnsCacheMutex.Lock()
+	insertColumnsCache[recordType] = columns
+	insertColumnsCacheMutex.Unlock()
+
+	return columns
+}
+
+// isZeroField reports whether the field at index of record is its zero value, or unreachable through a nil pointer
+func isZeroField(record reflect.Value, index []int) bool {
+	field, ok := fieldByIndexNoAlloc(record, index)
+	return !ok || field.IsZero()
+}
+
+func allZeroFields(records []reflect.Value, index []int) bool {
+	for _, record := range records {
+		if !isZeroField(record, index) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex, but allocates nil pointers to nested structs along the way
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldByIndexNoAlloc is like reflect.Value.FieldByIndex, but returns false instead of panicking when
+// it hits a nil pointer to a nested struct
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,223 @@
+package dbr
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mappingTestRecord struct {
+	Id        int64
+	Name      string
+	Email     NullString
+	CreatedAt NullTime
+	Settings  struct {
+		Theme    string
+		Language string
+	}
+	Score  float64
+	Active bool
+}
+
+var mappingTestColumns = []string{"id", "name", "email", "created_at", "theme", "language", "score", "active"}
+
+func TestCalculateFieldMappingCached(t *testing.T) {
+	s := createFakeSession()
+	recordType := reflect.TypeOf(mappingTestRecord{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mapping, err := s.calculateFieldMapping(recordType, mappingTestColumns, true)
+			if assert.NoError(t, err) {
+				assert.Equal(t, mapping.fieldMap, [][]int{{0}, {1}, {2}, {3}, {4, 0}, {4, 1}, {5}, {6}})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Other column sets for the same type are looked up in the same cached index
+	mapping, err := s.calculateFieldMapping(recordType, []string{"score", "wat"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, mapping.fieldMap, [][]int{{5}, nil})
+
+	_, err = s.calculateFieldMapping(recordType, []string{"score", "wat"}, true)
+	assert.Equal(t, err.Error(), "couldn't find match for column wat")
+}
+
+type deepContact struct {
+	Email NullString
+}
+
+type deepInfo struct {
+	Name    string
+	Contact deepContact
+}
+
+type deepExtra struct {
+	Key NullString
+}
+
+type deepPerson struct {
+	Id    int64
+	Info  deepInfo
+	Extra *deepExtra
+}
+
+type shadowInner struct {
+	Id    int64
+	Name  string
+	Title string
+	Label string `db:"label"`
+}
+
+type shadowOther struct {
+	Title string
+	Label string
+}
+
+type shadowOuter struct {
+	Id int64
+	shadowInner
+	Other *shadowOther
+}
+
+type shadowTie struct {
+	shadowInner
+	shadowOther
+}
+
+type treeNode struct {
+	Id     int64
+	Parent *treeNode
+}
+
+func TestBuildFieldIndex(t *testing.T) {
+	// Nested structs, values and pointers, at any depth
+	index := buildFieldIndex(reflect.TypeOf(deepPerson{}))
+	assert.Equal(t, index["id"], []int{0})
+	assert.Equal(t, index["name"], []int{1, 0})
+	assert.Equal(t, index["email"], []int{1, 1, 0})
+	assert.Equal(t, index["key"], []int{2, 0})
+
+	// Shallower fields shadow deeper ones, and embedded fields win ties with fields of named structs
+	index = buildFieldIndex(reflect.TypeOf(shadowOuter{}))
+	assert.Equal(t, index["id"], []int{0})
+	assert.Equal(t, index["name"], []int{1, 1})
+	assert.Equal(t, index["title"], []int{1, 2})
+	assert.Equal(t, index["label"], []int{1, 3})
+
+	// Tagged fields win the remaining ties, and ties after that are ambiguous
+	index = buildFieldIndex(reflect.TypeOf(shadowTie{}))
+	assert.Equal(t, index["label"], []int{0, 3})
+	_, ok := index["title"]
+	assert.Equal(t, ok, false)
+
+	// Pointer cycles aren't followed
+	index = buildFieldIndex(reflect.TypeOf(treeNode{}))
+	assert.Equal(t, index["id"], []int{0})
+	assert.Equal(t, len(index), 2)
+}
+
+func TestInsertNestedRecordToSql(t *testing.T) {
+	s := createFakeSession()
+
+	person := deepPerson{Id: 1, Info: deepInfo{Name: "Barack"}}
+	person.Info.Contact.Email.Scan("obama@whitehouse.gov")
+
+	sql, args, err := s.InsertInto("dbr_people").Columns("name", "email", "key").Record(&person).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "INSERT INTO dbr_people (`name`,`email`,`key`) VALUES (?,?,?)")
+	assert.Equal(t, args, []interface{}{"Barack", person.Info.Contact.Email, nil})
+
+	person.Extra = &deepExtra{}
+	person.Extra.Key.Scan("44")
+	_, args, err = s.InsertInto("dbr_people").Columns("name", "email", "key").Record(&person).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, args, []interface{}{"Barack", person.Info.Contact.Email, person.Extra.Key})
+}
+
+func TestNestedRecordReal(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	person := deepPerson{Info: deepInfo{Name: "Barack"}, Extra: &deepExtra{}}
+	person.Info.Contact.Email.Scan("obama@whitehouse.gov")
+	person.Extra.Key.Scan("44")
+
+	res, err := s.InsertInto("dbr_people").Columns("name", "email", "key").Record(&person).Exec()
+	assert.NoError(t, err)
+	id, err := res.LastInsertId()
+	assert.NoError(t, err)
+	assert.Equal(t, person.Id, id)
+
+	var loaded deepPerson
+	err = s.Select("*").From("dbr_people").Where("id = ?", id).LoadStruct(&loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, loaded.Id, id)
+	assert.Equal(t, loaded.Info.Name, "Barack")
+	assert.Equal(t, loaded.Info.Contact.Email.String, "obama@whitehouse.gov")
+	if assert.NotEqual(t, loaded.Extra, (*deepExtra)(nil)) {
+		assert.Equal(t, loaded.Extra.Key.String, "44")
+	}
+
+	var people []deepPerson
+	count, err := s.Select("id", "name", "email").From("dbr_people").OrderBy("id").LoadStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, count, 3)
+	if len(people) == 3 {
+		assert.Equal(t, people[0].Info.Name, "Jonathan")
+		assert.Equal(t, people[1].Info.Contact.Email.String, "zavorotni@jadius.com")
+		assert.Equal(t, people[2].Info.Name, "Barack")
+	}
+}
+
+type prefixCustomer struct {
+	Id   int64
+	Name string
+}
+
+type prefixOrder struct {
+	Id       int64
+	Name     string
+	Customer prefixCustomer  `db:"customer,prefix=customer_"`
+	Referrer *prefixCustomer `db:"referrer"`
+}
+
+func TestBuildFieldIndexPrefixes(t *testing.T) {
+	index := buildFieldIndex(reflect.TypeOf(prefixOrder{}))
+
+	assert.Equal(t, index["id"], []int{0})
+	assert.Equal(t, index["name"], []int{1})
+	assert.Equal(t, index["customer_id"], []int{2, 0})
+	assert.Equal(t, index["customer_name"], []int{2, 1})
+	assert.Equal(t, index["customer.id"], []int{2, 0})
+	assert.Equal(t, index["referrer.id"], []int{3, 0})
+	assert.Equal(t, index["referrer.name"], []int{3, 1})
+}
+
+func TestLoadJoinedNestedStructs(t *testing.T) {
+	s := createRealSessionWithFixtures()
+
+	var orders []*prefixOrder
+	count, err := s.SelectBySql(`SELECT p.id, p.name, p.id AS customer_id, p.name AS customer_name, r.id AS "referrer.id", r.name AS "referrer.name"
+		FROM dbr_people p LEFT JOIN dbr_people r ON r.id = p.id + 1 ORDER BY p.id`).LoadStructs(&orders)
+
+	assert.NoError(t, err)
+	assert.Equal(t, count, 2)
+	if len(orders) == 2 {
+		assert.Equal(t, orders[0].Name, "Jonathan")
+		assert.Equal(t, orders[0].Customer.Id, orders[0].Id)
+		assert.Equal(t, orders[0].Customer.Name, "Jonathan")
+		if assert.NotEqual(t, orders[0].Referrer, (*prefixCustomer)(nil)) {
+			assert.Equal(t, orders[0].Referrer.Name, "Dmitri")
+		}
+
+		// All of the referrer's columns are NULL
+		assert.Equal(t, orders[1].Name, "Dmitri")
+		assert.Equal(t, orders[1].Referrer, (*prefixCustomer)(nil))
+	}
+}
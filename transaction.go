@@ -14,7 +14,7 @@ type Tx struct {
 func (sess *Session) Begin() (*Tx, error) {
 	tx, err := sess.cxn.Db.Begin()
 	if err != nil {
-		return nil, sess.EventErr("dbr.begin.error", err)
+		return nil, newError("begin", "", "", sess.EventErr("dbr.begin.error", err))
 	} else {
 		sess.Event("dbr.begin")
 	}
@@ -29,7 +29,7 @@ func (sess *Session) Begin() (*Tx, error) {
 func (tx *Tx) Commit() error {
 	err := tx.Tx.Commit()
 	if err != nil {
-		return tx.EventErr("dbr.commit.error", err)
+		return newError("commit", "", "", tx.EventErr("dbr.commit.error", err))
 	} else {
 		tx.Event("dbr.commit")
 	}
@@ -40,7 +40,7 @@ func (tx *Tx) Commit() error {
 func (tx *Tx) Rollback() error {
 	err := tx.Tx.Rollback()
 	if err != nil {
-		return tx.EventErr("dbr.rollback", err)
+		return newError("rollback", "", "", tx.EventErr("dbr.rollback", err))
 	} else {
 		tx.Event("dbr.rollback")
 	}
@@ -3,7 +3,9 @@ package dbr
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
 	"time"
 )
 
@@ -15,7 +17,9 @@ type UpdateBuilder struct {
 	RawFullSql   string
 	RawArguments []interface{}
 
+	OptimizerHints []string
 	Table          string
+	IndexHints     []string
 	SetClauses     []*setClause
 	WhereFragments []*whereFragment
 	OrderBys       []string
@@ -23,11 +27,21 @@ type UpdateBuilder struct {
 	LimitValid     bool
 	OffsetCount    uint64
 	OffsetValid    bool
+
+	immutable bool
+	unscoped  bool
+	err       error
 }
 
 type setClause struct {
 	column string
 	value  interface{}
+	record *updateRecord // set by SetRecord, in which case the value is read from the record when the SQL is generated
+}
+
+// updateRecord is the record of one SetRecord call, shared by its set clauses
+type updateRecord struct {
+	value interface{}
 }
 
 // Update creates a new UpdateBuilder for the given table
@@ -68,8 +82,41 @@ func (tx *Tx) UpdateBySql(sql string, args ...interface{}) *UpdateBuilder {
 	}
 }
 
+// Clone returns a copy of the builder that can be extended without affecting the original
+func (b *UpdateBuilder) Clone() *UpdateBuilder {
+	c := *b
+	c.RawArguments = cloneArgs(b.RawArguments)
+	c.OptimizerHints = cloneStrings(b.OptimizerHints)
+	c.IndexHints = cloneStrings(b.IndexHints)
+	if b.SetClauses != nil {
+		c.SetClauses = make([]*setClause, len(b.SetClauses))
+		for i, sc := range b.SetClauses {
+			c.SetClauses[i] = &setClause{column: sc.column, value: sc.value, record: sc.record}
+		}
+	}
+	c.WhereFragments = cloneWhereFragments(b.WhereFragments)
+	c.OrderBys = cloneStrings(b.OrderBys)
+	return &c
+}
+
+// Immutable returns a copy of the builder on which every chained call returns a modified copy
+// instead of changing the builder itself
+func (b *UpdateBuilder) Immutable() *UpdateBuilder {
+	c := b.Clone()
+	c.immutable = true
+	return c
+}
+
+func (b *UpdateBuilder) mutable() *UpdateBuilder {
+	if b.immutable {
+		return b.Clone()
+	}
+	return b
+}
+
 // Set appends a column/value pair for the statement
 func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b = b.mutable()
 	b.SetClauses = append(b.SetClauses, &setClause{column: column, value: value})
 	return b
 }
@@ -82,20 +129,68 @@ func (b *UpdateBuilder) SetMap(clauses map[string]interface{}) *UpdateBuilder {
 	return b
 }
 
+// SetRecord appends a column/value pair for each of the given columns, taking the values from the
+// struct fields they map to when the SQL is generated. Fields with a converter, eg `db:"settings,json"`, are converted.
+// If the record implements BeforeUpdater, Exec calls BeforeUpdate before reading the values.
+func (b *UpdateBuilder) SetRecord(record interface{}, columns ...string) *UpdateBuilder {
+	b = b.mutable()
+	rec := &updateRecord{value: record}
+	for _, col := range columns {
+		b.SetClauses = append(b.SetClauses, &setClause{column: col, record: rec})
+	}
+	return b
+}
+
+// OptimizerHint appends an optimizer hint such as "MAX_EXECUTION_TIME(1000)" to the statement
+func (b *UpdateBuilder) OptimizerHint(hint string) *UpdateBuilder {
+	b = b.mutable()
+	b.OptimizerHints = append(b.OptimizerHints, hint)
+	return b
+}
+
+// UseIndex appends a USE INDEX hint for the table
+func (b *UpdateBuilder) UseIndex(indexes ...string) *UpdateBuilder {
+	b = b.mutable()
+	b.IndexHints = append(b.IndexHints, indexHint("USE", indexes))
+	return b
+}
+
+// ForceIndex appends a FORCE INDEX hint for the table
+func (b *UpdateBuilder) ForceIndex(indexes ...string) *UpdateBuilder {
+	b = b.mutable()
+	b.IndexHints = append(b.IndexHints, indexHint("FORCE", indexes))
+	return b
+}
+
+// IgnoreIndex appends an IGNORE INDEX hint for the table
+func (b *UpdateBuilder) IgnoreIndex(indexes ...string) *UpdateBuilder {
+	b = b.mutable()
+	b.IndexHints = append(b.IndexHints, indexHint("IGNORE", indexes))
+	return b
+}
+
 // Where appends a WHERE clause to the statement
 func (b *UpdateBuilder) Where(whereSqlOrMap interface{}, args ...interface{}) *UpdateBuilder {
-	b.WhereFragments = append(b.WhereFragments, newWhereFragment(whereSqlOrMap, args))
+	b = b.mutable()
+	fragment, err := newWhereFragment(whereSqlOrMap, args)
+	if err != nil {
+		b.recordError(err)
+		return b
+	}
+	b.WhereFragments = append(b.WhereFragments, fragment)
 	return b
 }
 
 // OrderBy appends a column to ORDER the statement by
 func (b *UpdateBuilder) OrderBy(ord string) *UpdateBuilder {
+	b = b.mutable()
 	b.OrderBys = append(b.OrderBys, ord)
 	return b
 }
 
 // OrderDir appends a column to ORDER the statement by with a given direction
 func (b *UpdateBuilder) OrderDir(ord string, isAsc bool) *UpdateBuilder {
+	b = b.mutable()
 	if isAsc {
 		b.OrderBys = append(b.OrderBys, ord+" ASC")
 	} else {
@@ -106,6 +201,7 @@ func (b *UpdateBuilder) OrderDir(ord string, isAsc bool) *UpdateBuilder {
 
 // Limit sets a limit for the statement; overrides any existing LIMIT
 func (b *UpdateBuilder) Limit(limit uint64) *UpdateBuilder {
+	b = b.mutable()
 	b.LimitCount = limit
 	b.LimitValid = true
 	return b
@@ -113,30 +209,54 @@ func (b *UpdateBuilder) Limit(limit uint64) *UpdateBuilder {
 
 // Offset sets an offset for the statement; overrides any existing OFFSET
 func (b *UpdateBuilder) Offset(offset uint64) *UpdateBuilder {
+	b = b.mutable()
 	b.OffsetCount = offset
 	b.OffsetValid = true
 	return b
 }
 
+// recordError records err, the first error building the statement, to be returned by ToSql and Exec
+func (b *UpdateBuilder) recordError(err error) {
+	err = b.buildError(err)
+	if b.err == nil {
+		b.err = err
+	}
+}
+
 // ToSql serialized the UpdateBuilder to a SQL string
-// It returns the string with placeholders and a slice of query arguments
-func (b *UpdateBuilder) ToSql() (string, []interface{}) {
+// It returns the string with placeholders and a slice of query arguments, or an error if the statement is invalid
+// BeforeUpdate hooks aren't called, only Exec calls them
+func (b *UpdateBuilder) ToSql() (string, []interface{}, error) {
+	return b.toSql()
+}
+
+func (b *UpdateBuilder) toSql() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
 	if b.RawFullSql != "" {
-		return b.RawFullSql, b.RawArguments
+		return b.RawFullSql, b.RawArguments, nil
+	}
+
+	if scoped := b.withDefaultScopes(); scoped != b {
+		return scoped.toSql()
 	}
 
 	if len(b.Table) == 0 {
-		panic("no table specified")
+		return "", nil, b.buildError(errors.New("no table specified"))
 	}
 	if len(b.SetClauses) == 0 {
-		panic("no set clauses specified")
+		return "", nil, b.buildError(errors.New("no set clauses specified"))
 	}
 
 	var sql bytes.Buffer
 	var args []interface{}
 
 	sql.WriteString("UPDATE ")
+	writeOptimizerHintsToSql(b.OptimizerHints, &sql)
 	sql.WriteString(b.Table)
+	writeIndexHintsToSql(b.IndexHints, &sql)
 	sql.WriteString(" SET ")
 
 	// Build SET clause SQL with placeholders and add values to args
@@ -145,7 +265,17 @@ func (b *UpdateBuilder) ToSql() (string, []interface{}) {
 			sql.WriteString(", ")
 		}
 		Quoter.writeQuotedColumn(c.column, &sql)
-		if e, ok := c.value.(*expr); ok {
+		if c.record != nil {
+			value, err := b.recordValue(c)
+			if err != nil {
+				return "", nil, err
+			}
+			sql.WriteString(" = ?")
+			args = append(args, value)
+		} else if e, ok := c.value.(*expr); ok {
+			if e.err != nil {
+				return "", nil, b.buildError(e.err)
+			}
 			sql.WriteString(" = ")
 			sql.WriteString(e.Sql)
 			args = append(args, e.Values...)
@@ -158,7 +288,9 @@ func (b *UpdateBuilder) ToSql() (string, []interface{}) {
 	// Write WHERE clause if we have any fragments
 	if len(b.WhereFragments) > 0 {
 		sql.WriteString(" WHERE ")
-		writeWhereFragmentsToSql(b.WhereFragments, &sql, &args)
+		if err := writeWhereFragmentsToSql(b.WhereFragments, &sql, &args); err != nil {
+			return "", nil, b.buildError(err)
+		}
 	}
 
 	// Ordering and limiting
@@ -182,17 +314,44 @@ func (b *UpdateBuilder) ToSql() (string, []interface{}) {
 		fmt.Fprint(&sql, b.OffsetCount)
 	}
 
-	return sql.String(), args
+	return sql.String(), args, nil
+}
+
+// recordValue reads the value of a SetRecord clause from its record
+func (b *UpdateBuilder) recordValue(c *setClause) (interface{}, error) {
+	ind := reflect.Indirect(reflect.ValueOf(c.record.value))
+	vals, err := b.valuesFor(ind.Type(), ind, []string{c.column})
+	if err != nil {
+		return nil, b.buildError(err)
+	}
+	return vals[0], nil
 }
 
 // Exec executes the statement represented by the UpdateBuilder
 // It returns the raw database/sql Result and an error if there was one
 func (b *UpdateBuilder) Exec() (sql.Result, error) {
-	sql, args := b.ToSql()
+	if b.err != nil {
+		return nil, b.errorKv("dbr.update.exec.to_sql", "", b.err, nil)
+	}
+	clauses, err := beforeUpdate(b.SetClauses)
+	if err != nil {
+		return nil, b.errorKv("dbr.update.exec.before_update", "", err, nil)
+	}
+	hooked := b
+	if len(clauses) > 0 && &clauses[0] != &b.SetClauses[0] {
+		c := *b
+		c.SetClauses = clauses
+		hooked = &c
+	}
+
+	sql, args, err := hooked.toSql()
+	if err != nil {
+		return nil, b.errorKv("dbr.update.exec.to_sql", sql, err, nil)
+	}
 
 	fullSql, err := Interpolate(sql, args)
 	if err != nil {
-		return nil, b.EventErrKv("dbr.update.exec.interpolate", err, kvs{"sql": fullSql})
+		return nil, b.errorKv("dbr.update.exec.interpolate", sql, err, kvs{"sql": fullSql})
 	}
 
 	// Start the timer:
@@ -201,8 +360,18 @@ func (b *UpdateBuilder) Exec() (sql.Result, error) {
 
 	result, err := b.runner.Exec(fullSql)
 	if err != nil {
-		return result, b.EventErrKv("dbr.update.exec.exec", err, kvs{"sql": fullSql})
+		return result, b.errorKv("dbr.update.exec.exec", sql, err, kvs{"sql": fullSql})
 	}
 
 	return result, nil
 }
+
+// errorKv reports err to the EventReceiver and returns it wrapped in an *Error with sql, the statement built so far, if any
+func (b *UpdateBuilder) errorKv(eventName, sql string, err error, kvs kvs) error {
+	if kvs == nil {
+		err = b.EventErr(eventName, err)
+	} else {
+		err = b.EventErrKv(eventName, err, kvs)
+	}
+	return newError("update", b.Table, sql, err)
+}
@@ -29,7 +29,8 @@ func BenchmarkUpdateValueMapSql(b *testing.B) {
 func TestUpdateAllToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Update("a").Set("b", 1).Set("c", 2).ToSql()
+	sql, args, err := s.Update("a").Set("b", 1).Set("c", 2).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "UPDATE a SET `b` = ?, `c` = ?")
 	assert.Equal(t, args, []interface{}{1, 2})
@@ -38,7 +39,8 @@ func TestUpdateAllToSql(t *testing.T) {
 func TestUpdateSingleToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Update("a").Set("b", 1).Set("c", 2).Where("id = ?", 1).ToSql()
+	sql, args, err := s.Update("a").Set("b", 1).Set("c", 2).Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "UPDATE a SET `b` = ?, `c` = ? WHERE (id = ?)")
 	assert.Equal(t, args, []interface{}{1, 2, 1})
@@ -47,7 +49,8 @@ func TestUpdateSingleToSql(t *testing.T) {
 func TestUpdateSetMapToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Update("a").SetMap(map[string]interface{}{"b": 1, "c": 2}).Where("id = ?", 1).ToSql()
+	sql, args, err := s.Update("a").SetMap(map[string]interface{}{"b": 1, "c": 2}).Where("id = ?", 1).ToSql()
+	assert.NoError(t, err)
 
 	if sql == "UPDATE a SET `b` = ?, `c` = ? WHERE (id = ?)" {
 		assert.Equal(t, args, []interface{}{1, 2, 1})
@@ -60,12 +63,14 @@ func TestUpdateSetMapToSql(t *testing.T) {
 func TestUpdateSetExprToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Update("a").Set("foo", 1).Set("bar", Expr("COALESCE(bar, 0) + 1")).Where("id = ?", 9).ToSql()
+	sql, args, err := s.Update("a").Set("foo", 1).Set("bar", Expr("COALESCE(bar, 0) + 1")).Where("id = ?", 9).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "UPDATE a SET `foo` = ?, `bar` = COALESCE(bar, 0) + 1 WHERE (id = ?)")
 	assert.Equal(t, args, []interface{}{1, 9})
 
-	sql, args = s.Update("a").Set("foo", 1).Set("bar", Expr("COALESCE(bar, 0) + ?", 2)).Where("id = ?", 9).ToSql()
+	sql, args, err = s.Update("a").Set("foo", 1).Set("bar", Expr("COALESCE(bar, 0) + ?", 2)).Where("id = ?", 9).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "UPDATE a SET `foo` = ?, `bar` = COALESCE(bar, 0) + ? WHERE (id = ?)")
 	assert.Equal(t, args, []interface{}{1, 2, 9})
@@ -74,12 +79,62 @@ func TestUpdateSetExprToSql(t *testing.T) {
 func TestUpdateTenStaringFromTwentyToSql(t *testing.T) {
 	s := createFakeSession()
 
-	sql, args := s.Update("a").Set("b", 1).Limit(10).Offset(20).ToSql()
+	sql, args, err := s.Update("a").Set("b", 1).Limit(10).Offset(20).ToSql()
+	assert.NoError(t, err)
 
 	assert.Equal(t, sql, "UPDATE a SET `b` = ? LIMIT 10 OFFSET 20")
 	assert.Equal(t, args, []interface{}{1})
 }
 
+func TestUpdateHintsToSql(t *testing.T) {
+	s := createFakeSession()
+
+	sql, args, err := s.Update("a").
+		OptimizerHint("NO_RANGE_OPTIMIZATION(a)").
+		UseIndex("idx_b").
+		Set("b", 1).
+		Where("c = ?", 2).
+		ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql, "UPDATE /*+ NO_RANGE_OPTIMIZATION(a) */ a USE INDEX (idx_b) SET `b` = ? WHERE (c = ?)")
+	assert.Equal(t, args, []interface{}{1, 2})
+}
+
+func TestUpdateClone(t *testing.T) {
+	s := createFakeSession()
+
+	base := s.Update("a").Set("b", 1).Where("c = ?", 2)
+	clone := base.Clone().Set("d", 3).Where("e = ?", 4)
+
+	sql, args, err := base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "UPDATE a SET `b` = ? WHERE (c = ?)")
+	assert.Equal(t, args, []interface{}{1, 2})
+
+	sql, args, err = clone.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, sql, "UPDATE a SET `b` = ?, `d` = ? WHERE (c = ?) AND (e = ?)")
+	assert.Equal(t, args, []interface{}{1, 3, 2, 4})
+}
+
+func TestUpdateBuildErrors(t *testing.T) {
+	s := createFakeSession()
+
+	_, _, err := s.Update("a").Set("b", 1).Where([]string{"c"}).ToSql()
+	assert.EqualError(t, err, "Invalid argument passed to Where. Pass a string or an Eq map.")
+
+	_, err = s.Update("a").Exec()
+	assert.EqualError(t, err, "dbr: update a: no set clauses specified")
+
+	sub := s.Select("COUNT(*)").As("c")
+	_, _, err = s.Update("a").Set("b", sub).ToSql()
+	assert.EqualError(t, err, "no table specified")
+
+	s.PanicOnBuildError = true
+	assert.Panics(t, func() { s.Update("a").Set("b", sub).ToSql() })
+}
+
 func TestUpdateKeywordColumnName(t *testing.T) {
 	s := createRealSessionWithFixtures()
 
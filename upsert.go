@@ -0,0 +1,177 @@
+package dbr
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// upsert holds what an InsertBuilder does with rows that conflict with an existing unique key
+type upsert struct {
+	target    []string // the ON CONFLICT columns
+	doNothing bool
+	updates   []*setClause
+}
+
+func (u *upsert) clone() *upsert {
+	c := *u
+	c.target = cloneStrings(u.target)
+	if u.updates != nil {
+		c.updates = make([]*setClause, len(u.updates))
+		for i, sc := range u.updates {
+			c.updates[i] = &setClause{column: sc.column, value: sc.value}
+		}
+	}
+	return &c
+}
+
+type excludedColumn string
+
+// Excluded refers to the value a column would have been given by the insert, for use as a value in
+// OnDuplicateKeyUpdateMap and DoUpdateMap. It's rendered as VALUES(`column`) for MySQL.
+func Excluded(column string) excludedColumn {
+	return excludedColumn(column)
+}
+
+// ConflictClause is the ON CONFLICT clause of an InsertBuilder, completed by DoNothing or DoUpdate
+type ConflictClause struct {
+	b *InsertBuilder
+}
+
+// OnDuplicateKeyUpdate makes the statement update the given columns of an existing row
+// with a conflicting unique key to the values that would have been inserted, eg:
+//
+//	ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)
+func (b *InsertBuilder) OnDuplicateKeyUpdate(columns ...string) *InsertBuilder {
+	b = b.mutable()
+	b.upsertUpdate(columns)
+	return b
+}
+
+// OnDuplicateKeyUpdateMap makes the statement update an existing row with a conflicting unique key, setting each column
+// to its value in the map. Values can be Expr, eg Expr("hits + 1"), or Excluded(column). Columns are written in sorted order.
+func (b *InsertBuilder) OnDuplicateKeyUpdateMap(clauses map[string]interface{}) *InsertBuilder {
+	b = b.mutable()
+	b.upsertUpdateMap(clauses)
+	return b
+}
+
+// OnConflict starts an upsert on a conflict with the unique key made of columns.
+// MySQL can't name the key, so it applies to any unique key, and the columns are only used by DoNothing.
+func (b *InsertBuilder) OnConflict(columns ...string) *ConflictClause {
+	b = b.mutable()
+	if b.Upsert == nil {
+		b.Upsert = &upsert{}
+	}
+	b.Upsert.target = columns
+	return &ConflictClause{b: b}
+}
+
+// DoNothing leaves an existing row with a conflicting unique key as it is.
+// MySQL renders it by setting the first conflict column, or the first inserted column, to itself,
+// which unlike INSERT IGNORE doesn't hide other errors.
+func (c *ConflictClause) DoNothing() *InsertBuilder {
+	c.b.Upsert.doNothing = true
+	c.b.Upsert.updates = nil
+	return c.b
+}
+
+// DoUpdate updates the given columns of an existing row with a conflicting unique key
+// to the values that would have been inserted, like OnDuplicateKeyUpdate
+func (c *ConflictClause) DoUpdate(columns ...string) *InsertBuilder {
+	c.b.upsertUpdate(columns)
+	return c.b
+}
+
+// DoUpdateMap updates an existing row with a conflicting unique key like OnDuplicateKeyUpdateMap
+func (c *ConflictClause) DoUpdateMap(clauses map[string]interface{}) *InsertBuilder {
+	c.b.upsertUpdateMap(clauses)
+	return c.b
+}
+
+func (b *InsertBuilder) upsertUpdate(columns []string) {
+	if len(columns) == 0 {
+		b.recordError(errors.New("no columns to update specified"))
+		return
+	}
+	if b.Upsert == nil {
+		b.Upsert = &upsert{}
+	}
+	b.Upsert.doNothing = false
+	for _, col := range columns {
+		b.Upsert.updates = append(b.Upsert.updates, &setClause{column: col, value: Excluded(col)})
+	}
+}
+
+func (b *InsertBuilder) upsertUpdateMap(clauses map[string]interface{}) {
+	columns := make([]string, 0, len(clauses))
+	for col := range clauses {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	if len(columns) == 0 {
+		b.recordError(errors.New("no columns to update specified"))
+		return
+	}
+	if b.Upsert == nil {
+		b.Upsert = &upsert{}
+	}
+	b.Upsert.doNothing = false
+	for _, col := range columns {
+		b.Upsert.updates = append(b.Upsert.updates, &setClause{column: col, value: clauses[col]})
+	}
+}
+
+// writeUpsertToSql writes the ON DUPLICATE KEY UPDATE clause of the statement, if it has one
+func (b *InsertBuilder) writeUpsertToSql(cols []string, sql *bytes.Buffer, args *[]interface{}) error {
+	u := b.Upsert
+	if u == nil {
+		return nil
+	}
+
+	updates := u.updates
+	if u.doNothing {
+		var column string
+		if len(u.target) > 0 {
+			column = u.target[0]
+		} else if len(cols) > 0 {
+			column = cols[0]
+		} else {
+			return errors.New("do nothing needs a conflict column")
+		}
+		updates = []*setClause{{column: column, value: Expr(quoteColumn(column))}}
+	}
+	if len(updates) == 0 {
+		return errors.New("on conflict needs DoNothing or DoUpdate")
+	}
+
+	sql.WriteString(" ON DUPLICATE KEY UPDATE ")
+	for i, c := range updates {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		Quoter.writeQuotedColumn(c.column, sql)
+		sql.WriteString(" = ")
+		switch v := c.value.(type) {
+		case excludedColumn:
+			Quoter.writeExcludedColumn(string(v), sql)
+		case *expr:
+			if v.err != nil {
+				return v.err
+			}
+			sql.WriteString(v.Sql)
+			*args = append(*args, v.Values...)
+		default:
+			sql.WriteRune('?')
+			*args = append(*args, v)
+		}
+	}
+	return nil
+}
+
+func quoteColumn(column string) string {
+	var buf bytes.Buffer
+	Quoter.writeQuotedColumn(column, &buf)
+	return buf.String()
+}
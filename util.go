@@ -21,3 +21,17 @@ func camelCaseToSnakeCase(name string) string {
 
 	return string(newstr)
 }
+
+func cloneStrings(strs []string) []string {
+	if strs == nil {
+		return nil
+	}
+	return append([]string(nil), strs...)
+}
+
+func cloneArgs(args []interface{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	return append([]interface{}(nil), args...)
+}
@@ -2,6 +2,7 @@ package dbr
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
 )
 
@@ -14,23 +15,43 @@ type whereFragment struct {
 	EqualityMap map[string]interface{}
 }
 
-func newWhereFragment(whereSqlOrMap interface{}, args []interface{}) *whereFragment {
+func newWhereFragment(whereSqlOrMap interface{}, args []interface{}) (*whereFragment, error) {
 	switch pred := whereSqlOrMap.(type) {
 	case string:
-		return &whereFragment{Condition: pred, Values: args}
+		return &whereFragment{Condition: pred, Values: args}, nil
 	case map[string]interface{}:
-		return &whereFragment{EqualityMap: pred}
+		return &whereFragment{EqualityMap: pred}, nil
 	case Eq:
-		return &whereFragment{EqualityMap: map[string]interface{}(pred)}
+		return &whereFragment{EqualityMap: map[string]interface{}(pred)}, nil
 	default:
-		panic("Invalid argument passed to Where. Pass a string or an Eq map.")
+		return nil, errors.New("Invalid argument passed to Where. Pass a string or an Eq map.")
 	}
+}
 
-	return nil
+func (f *whereFragment) clone() *whereFragment {
+	c := &whereFragment{Condition: f.Condition, Values: cloneArgs(f.Values)}
+	if f.EqualityMap != nil {
+		c.EqualityMap = make(map[string]interface{}, len(f.EqualityMap))
+		for k, v := range f.EqualityMap {
+			c.EqualityMap[k] = v
+		}
+	}
+	return c
+}
+
+func cloneWhereFragments(fragments []*whereFragment) []*whereFragment {
+	if fragments == nil {
+		return nil
+	}
+	c := make([]*whereFragment, len(fragments))
+	for i, f := range fragments {
+		c[i] = f.clone()
+	}
+	return c
 }
 
 // Invariant: only called when len(fragments) > 0
-func writeWhereFragmentsToSql(fragments []*whereFragment, sql *bytes.Buffer, args *[]interface{}) {
+func writeWhereFragmentsToSql(fragments []*whereFragment, sql *bytes.Buffer, args *[]interface{}) error {
 	anyConditions := false
 	for _, f := range fragments {
 		if f.Condition != "" {
@@ -48,9 +69,10 @@ func writeWhereFragmentsToSql(fragments []*whereFragment, sql *bytes.Buffer, arg
 		} else if f.EqualityMap != nil {
 			anyConditions = writeEqualityMapToSql(f.EqualityMap, sql, args, anyConditions)
 		} else {
-			panic("invalid equality map")
+			return errors.New("invalid equality map")
 		}
 	}
+	return nil
 }
 
 func writeEqualityMapToSql(eq map[string]interface{}, sql *bytes.Buffer, args *[]interface{}, anyConditions bool) bool {